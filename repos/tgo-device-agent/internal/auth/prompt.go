@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Confirm prompts the operator for a username and passphrase and verifies
+// them against the htpasswd file, returning an error if the prompt can't be
+// completed or the credentials don't match.
+//
+// When stdin is a controlling TTY, the prompt is interactive. Otherwise
+// (headless installs, e.g. running under a service manager) it reads one
+// "user:passphrase" line from promptPath, which may be an existing named
+// pipe or a unix socket path to listen on.
+func (a *OperatorAuth) Confirm(promptPath string) error {
+	user, pass, err := readCredentials(promptPath)
+	if err != nil {
+		return fmt.Errorf("operator auth: %w", err)
+	}
+	if !a.Verify(user, pass) {
+		return errors.New("operator auth: passphrase rejected")
+	}
+	return nil
+}
+
+func readCredentials(promptPath string) (user, pass string, err error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return promptTTY()
+	}
+	return promptPipeOrSocket(promptPath)
+}
+
+func promptTTY() (user, pass string, err error) {
+	fmt.Fprintln(os.Stderr, "Operator confirmation required to complete device enrollment.")
+	fmt.Fprint(os.Stderr, "Username: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", "", fmt.Errorf("read username: %w", err)
+	}
+	user = strings.TrimSpace(line)
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", "", fmt.Errorf("read passphrase: %w", err)
+	}
+	return user, string(passBytes), nil
+}
+
+// promptPipeOrSocket serves the same prompt for headless installs with no
+// controlling TTY: an install script (or an operator connected over SSH to
+// a separate session) writes "user:passphrase\n" to promptPath. If
+// promptPath already exists as a named pipe it's read directly; otherwise
+// it's bound as a unix socket and the first connection is read.
+func promptPipeOrSocket(promptPath string) (user, pass string, err error) {
+	if promptPath == "" {
+		return "", "", errors.New("no controlling TTY and no operator prompt socket configured")
+	}
+
+	if info, statErr := os.Stat(promptPath); statErr == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		f, err := os.Open(promptPath)
+		if err != nil {
+			return "", "", fmt.Errorf("open prompt pipe: %w", err)
+		}
+		defer f.Close()
+		return readCredentialLine(f)
+	}
+
+	os.Remove(promptPath) // clear a stale socket left by a prior run
+	ln, err := net.Listen("unix", promptPath)
+	if err != nil {
+		return "", "", fmt.Errorf("listen on prompt socket: %w", err)
+	}
+	defer ln.Close()
+	defer os.Remove(promptPath)
+
+	// net.Listen ignores umask for unix sockets, so without this any local
+	// user/process could connect and attempt the bind-code confirmation,
+	// defeating the "physically present operator" guarantee this prompt
+	// exists for.
+	if err := os.Chmod(promptPath, 0o600); err != nil {
+		return "", "", fmt.Errorf("restrict prompt socket permissions: %w", err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return "", "", fmt.Errorf("accept on prompt socket: %w", err)
+	}
+	defer conn.Close()
+	return readCredentialLine(conn)
+}
+
+func readCredentialLine(r io.Reader) (user, pass string, err error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", "", fmt.Errorf("read credentials: %w", err)
+	}
+	user, pass, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok || user == "" || pass == "" {
+		return "", "", errors.New(`expected "user:passphrase" line`)
+	}
+	return user, pass, nil
+}
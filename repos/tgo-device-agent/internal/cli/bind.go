@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tgoai/tgo-device-agent/internal/auth"
+	"github.com/tgoai/tgo-device-agent/internal/transport"
+)
+
+var bindCmd = &cobra.Command{
+	Use:   "bind",
+	Short: "Register this device with a bind code and save the resulting device token",
+	Long: "bind performs a single connect-and-authenticate cycle using a bind\n" +
+		"code issued by the controller, saves the device token it returns, and\n" +
+		"exits. Run \"tgo-device-agent run\" afterwards to start the agent.",
+	RunE: runBind,
+}
+
+func init() {
+	bindCmd.Flags().StringVar(&cfg.BindCode, "code", "", "Bind code for first-time registration")
+	bindCmd.MarkFlagRequired("code")
+}
+
+func runBind(cmd *cobra.Command, args []string) error {
+	operatorAuth, err := loadOperatorAuth()
+	if err != nil {
+		return err
+	}
+
+	client := transport.NewClient(cfg, nil, operatorAuth, nil, nil)
+	if err := client.Bind(cmd.Context()); err != nil {
+		return fmt.Errorf("bind: %w", err)
+	}
+
+	fmt.Printf("Bound device %s (project %s); token saved to %s\n",
+		client.DeviceID(), client.ProjectID(), cfg.TokenFile)
+	return nil
+}
+
+// loadOperatorAuth loads cfg.OperatorAuthFile when operator auth is
+// required, shared by both "bind" and "run".
+func loadOperatorAuth() (*auth.OperatorAuth, error) {
+	if !cfg.OperatorAuthRequired {
+		return nil, nil
+	}
+	if cfg.OperatorAuthFile == "" {
+		return nil, fmt.Errorf("operator_auth_required is set but operator_auth_file is empty")
+	}
+	return auth.Load(cfg.OperatorAuthFile)
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect or edit the on-disk JSON or YAML config file",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective config (defaults plus --config/flag overrides) as JSON",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printJSON(cfg)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a single field from the on-disk config file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		doc, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		v, ok := doc[args[0]]
+		if !ok {
+			return fmt.Errorf("key %q is not set in %s", args[0], configFilePath())
+		}
+		return printJSON(v)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a single field in the on-disk config file",
+	Long: "set writes a single top-level field into the on-disk config file,\n" +
+		"creating it if necessary. <value> is parsed as JSON when possible\n" +
+		"(so `true`, `123`, and `[\"a\",\"b\"]` all work), falling back to a\n" +
+		"plain string otherwise. Field names match Config's JSON tags, e.g.\n" +
+		"server_host, log_level, operator_auth_required.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		doc, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		doc[args[0]] = parseConfigValue(args[1])
+		return writeConfigFile(doc)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd, configGetCmd, configSetCmd)
+}
+
+// configFilePath returns the config file --config points at, or the
+// default path under the user's home directory.
+func configFilePath() string {
+	if configFile != "" {
+		return configFile
+	}
+	return config.DefaultConfigFile()
+}
+
+// isYAMLPath reports whether path should be read/written as YAML rather
+// than JSON, based on its extension, matching config.Config.LoadFromFile.
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+func readConfigFile() (map[string]interface{}, error) {
+	path := configFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	doc := map[string]interface{}{}
+	if isYAMLPath(path) {
+		err = yaml.Unmarshal(data, &doc)
+	} else {
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+func writeConfigFile(doc map[string]interface{}) error {
+	path := configFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+
+	var data []byte
+	var err error
+	if isYAMLPath(path) {
+		data, err = yaml.Marshal(doc)
+	} else {
+		data, err = json.MarshalIndent(doc, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+// parseConfigValue interprets raw as JSON when it parses as such (numbers,
+// booleans, arrays, objects, quoted strings), otherwise stores it verbatim
+// as a string so `config set device_name "my laptop"` works without quoting.
+func parseConfigValue(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err == nil {
+		return v
+	}
+	return raw
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package cli implements the tgo-device-agent command-line interface: a
+// cobra command tree with "bind" (one-shot enrollment), "run" (the
+// long-running agent), "config" (inspect/edit the on-disk config file),
+// "tools" (inspect the built-in tool registry), and "version". Splitting
+// these out keeps first-time provisioning (bind) separate from
+// steady-state operation (run), instead of multiplexing both off whether
+// --bind-code was set.
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/logging"
+)
+
+const version = "1.0.0"
+
+// cfg is shared by every subcommand: persistent root flags and config-file
+// loading populate it in PersistentPreRunE before any subcommand runs.
+var cfg = config.DefaultConfig()
+
+var (
+	configFile string
+	serverAddr string
+)
+
+var rootCmd = &cobra.Command{
+	Use:          "tgo-device-agent",
+	Short:        "Device-side agent for tgo-device-control",
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if configFile != "" {
+			if err := cfg.LoadFromFile(configFile); err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+		}
+
+		if serverAddr != "" {
+			parts := strings.SplitN(serverAddr, ":", 2)
+			cfg.ServerHost = parts[0]
+			if len(parts) == 2 {
+				fmt.Sscanf(parts[1], "%d", &cfg.ServerPort)
+			}
+		}
+
+		if err := logging.Setup(cfg); err != nil {
+			return fmt.Errorf("setting up logging: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to config file (JSON, or YAML with a .yaml/.yml extension)")
+	rootCmd.PersistentFlags().StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level: debug, info, warn, error")
+	rootCmd.PersistentFlags().StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Log encoding for stderr: text, json")
+	rootCmd.PersistentFlags().StringVar(&serverAddr, "server", "", "Server address host:port (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Discovery.Mode, "discovery", cfg.Discovery.Mode, "Server discovery mode: static, dns-srv, nats-kv, mdns (default static, using --server/config)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Discovery.Domain, "discovery-domain", cfg.Discovery.Domain, "Domain to resolve SRV records under when --discovery=dns-srv")
+
+	rootCmd.AddCommand(bindCmd, runCmd, configCmd, toolsCmd, versionCmd)
+}
+
+// Execute runs the root command, parsing os.Args.
+func Execute() error {
+	return rootCmd.Execute()
+}
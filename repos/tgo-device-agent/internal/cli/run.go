@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tgoai/tgo-device-agent/internal/auth"
+	"github.com/tgoai/tgo-device-agent/internal/discovery"
+	"github.com/tgoai/tgo-device-agent/internal/logging"
+	"github.com/tgoai/tgo-device-agent/internal/observability"
+	"github.com/tgoai/tgo-device-agent/internal/supervisor"
+	"github.com/tgoai/tgo-device-agent/internal/tools"
+	"github.com/tgoai/tgo-device-agent/internal/transport"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the long-running agent using a previously saved device token",
+	Long: "run connects using the device token saved by \"bind\" and serves\n" +
+		"tool calls until interrupted. It refuses to start if no token has been\n" +
+		"saved yet.",
+	RunE: runRun,
+}
+
+func init() {
+	runCmd.Flags().StringVar(&cfg.DeviceName, "name", cfg.DeviceName, "Device display name")
+	runCmd.Flags().StringVar(&cfg.WorkRoot, "work-root", cfg.WorkRoot, "Root directory for file operations")
+	runCmd.Flags().StringVar(&cfg.MetricsAddr, "metrics-addr", cfg.MetricsAddr, "Address to serve /metrics and /healthz on (empty disables)")
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	cfg.LoadTokenFromFile()
+	if cfg.DeviceToken == "" {
+		return fmt.Errorf("no saved device token in %s; run %q first", cfg.TokenFile, "tgo-device-agent bind --code CODE")
+	}
+
+	slog.Info("tgo-device-agent starting",
+		"version", version,
+		"server", fmt.Sprintf("%s:%d", cfg.ServerHost, cfg.ServerPort),
+		"work_root", cfg.WorkRoot,
+		"os", runtime.GOOS,
+		"arch", runtime.GOARCH,
+	)
+
+	operatorAuth, err := loadOperatorAuth()
+	if err != nil {
+		return err
+	}
+
+	// rec stays nil (and so does the Metrics interface passed below) unless
+	// --metrics-addr is set, so the registry/client metrics hooks are
+	// no-ops by default.
+	var rec *observability.Recorder
+	var metrics observability.Metrics
+	if cfg.MetricsAddr != "" {
+		rec = observability.NewRecorder(version, runtime.GOOS, runtime.GOARCH)
+		metrics = rec
+	}
+
+	loggers, err := logging.SetupLoggers(cfg)
+	if err != nil {
+		return fmt.Errorf("setting up per-tool-group logging: %w", err)
+	}
+
+	resolver, err := discovery.New(cfg)
+	if err != nil {
+		return fmt.Errorf("configuring discovery: %w", err)
+	}
+
+	registry := tools.NewRegistry(cfg, metrics, loggers)
+	client := transport.NewClient(cfg, registry, operatorAuth, metrics, resolver)
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		for sig := range sigCh {
+			if sig == syscall.SIGHUP {
+				reloadOperatorAuth(operatorAuth)
+				continue
+			}
+			slog.Info("received signal, shutting down", "signal", sig)
+			cancel()
+			return
+		}
+	}()
+
+	if rec != nil {
+		metricsSrv := observability.NewServer(cfg.MetricsAddr, rec)
+		go func() {
+			if err := metricsSrv.Run(ctx); err != nil {
+				slog.Warn("metrics server stopped", "error", err)
+			}
+		}()
+		slog.Info("metrics server listening", "addr", cfg.MetricsAddr)
+	}
+
+	sup := supervisor.New(client, cfg, metrics)
+	if err := sup.Serve(ctx); err != nil {
+		return fmt.Errorf("agent exited with error: %w", err)
+	}
+	slog.Info("agent stopped gracefully")
+	return nil
+}
+
+// reloadOperatorAuth re-parses the operator-auth htpasswd file on SIGHUP,
+// so operators can rotate or revoke passphrases without restarting the
+// agent. It's a no-op when operator auth is disabled.
+func reloadOperatorAuth(operatorAuth *auth.OperatorAuth) {
+	if operatorAuth == nil {
+		slog.Info("received SIGHUP, nothing to reload (operator auth disabled)")
+		return
+	}
+	if err := operatorAuth.Reload(); err != nil {
+		slog.Warn("failed to reload operator auth file", "error", err)
+		return
+	}
+	slog.Info("reloaded operator auth file", "path", cfg.OperatorAuthFile)
+}
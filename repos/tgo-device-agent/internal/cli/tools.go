@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tgoai/tgo-device-agent/internal/tools"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Inspect the built-in tool registry",
+}
+
+var toolsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered tool names and their JSON Schema definitions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry := tools.NewRegistry(cfg, nil, nil)
+		return printJSON(registry.ListTools())
+	},
+}
+
+func init() {
+	toolsCmd.AddCommand(toolsListCmd)
+}
@@ -0,0 +1,18 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the agent version",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("tgo-device-agent v%s (%s/%s)\n", version, runtime.GOOS, runtime.GOARCH)
+		return nil
+	},
+}
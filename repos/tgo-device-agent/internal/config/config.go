@@ -6,47 +6,158 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all runtime settings for the device agent.
 type Config struct {
 	// Server connection
-	ServerHost string `json:"server_host"`
-	ServerPort int    `json:"server_port"`
+	ServerHost string `json:"server_host" yaml:"server_host"`
+	ServerPort int    `json:"server_port" yaml:"server_port"`
+	// Transport selects the wire transport: "tcp" (default, raw newline-
+	// delimited JSON), "ws", or "wss". ws/wss let the agent sit behind an
+	// HTTPS-terminating load balancer that a raw TCP socket can't pass through.
+	Transport string `json:"transport" yaml:"transport"`
+
+	// Discovery controls how the agent finds its tgo-device-control
+	// endpoint. The zero value (mode "static") is ServerHost/ServerPort
+	// exactly as before discovery existed.
+	Discovery DiscoveryConfig `json:"discovery,omitempty" yaml:"discovery,omitempty"`
 
 	// Authentication
-	BindCode    string `json:"bind_code,omitempty"`
-	DeviceToken string `json:"device_token,omitempty"`
-	TokenFile   string `json:"token_file"`
+	BindCode    string `json:"bind_code,omitempty" yaml:"bind_code,omitempty"`
+	DeviceToken string `json:"device_token,omitempty" yaml:"device_token,omitempty"`
+	TokenFile   string `json:"token_file" yaml:"token_file"`
+
+	// OperatorAuthRequired gates bind-code enrollment on a local operator
+	// confirming the bind with a passphrase, on top of the bind code itself.
+	// This defeats a stolen-bind-code enrollment attack: the code alone is
+	// no longer enough, since it's checked against OperatorAuthFile with a
+	// human physically present at the device (or piping credentials in on
+	// a headless install).
+	OperatorAuthRequired bool   `json:"operator_auth_required" yaml:"operator_auth_required"`
+	OperatorAuthFile     string `json:"operator_auth_file,omitempty" yaml:"operator_auth_file,omitempty"`
 
 	// Device info
-	DeviceName string `json:"device_name"`
+	DeviceName string `json:"device_name" yaml:"device_name"`
 
 	// Reconnection
-	ReconnectInitialDelay time.Duration `json:"-"`
-	ReconnectMaxDelay     time.Duration `json:"-"`
-	MaxReconnectAttempts  int           `json:"max_reconnect_attempts"` // 0 = unlimited
+	ReconnectInitialDelay time.Duration `json:"-" yaml:"-"`
+	ReconnectMaxDelay     time.Duration `json:"-" yaml:"-"`
+	MaxReconnectAttempts  int           `json:"max_reconnect_attempts" yaml:"max_reconnect_attempts"` // 0 = unlimited
 
 	// Heartbeat
-	HeartbeatInterval time.Duration `json:"-"`
+	HeartbeatInterval time.Duration `json:"-" yaml:"-"`
+
+	// WriteTimeout bounds how long a single outgoing JSON-RPC frame may take
+	// to write before the connection is considered dead and reconnected.
+	WriteTimeout time.Duration `json:"-" yaml:"-"`
 
 	// Sandbox – file operations
-	WorkRoot      string   `json:"work_root"`
-	AllowedPaths  []string `json:"allowed_paths,omitempty"`
-	DeniedPaths   []string `json:"denied_paths,omitempty"`
-	MaxReadBytes  int64    `json:"max_read_bytes"`
-	MaxWriteBytes int64    `json:"max_write_bytes"`
+	WorkRoot      string   `json:"work_root" yaml:"work_root"`
+	AllowedPaths  []string `json:"allowed_paths,omitempty" yaml:"allowed_paths,omitempty"`
+	DeniedPaths   []string `json:"denied_paths,omitempty" yaml:"denied_paths,omitempty"`
+	MaxReadBytes  int64    `json:"max_read_bytes" yaml:"max_read_bytes"`
+	MaxWriteBytes int64    `json:"max_write_bytes" yaml:"max_write_bytes"`
+	// MaxJournalBytes bounds the on-disk size of the mutation journal (see
+	// internal/journal) that backs fs_history/fs_revert. Oldest entries are
+	// evicted first once the ring exceeds this size. 0 disables eviction.
+	MaxJournalBytes int64 `json:"max_journal_bytes" yaml:"max_journal_bytes"`
 
 	// Sandbox – shell execution
-	ShellTimeout     time.Duration `json:"-"`
-	MaxOutputBytes   int64         `json:"max_output_bytes"`
-	BlockedCommands  []string      `json:"blocked_commands,omitempty"`
-	AllowedShells    []string      `json:"allowed_shells,omitempty"`
+	ShellTimeout    time.Duration `json:"-" yaml:"-"`
+	MaxOutputBytes  int64         `json:"max_output_bytes" yaml:"max_output_bytes"`
+	BlockedCommands []string      `json:"blocked_commands,omitempty" yaml:"blocked_commands,omitempty"`
+	AllowedShells   []string      `json:"allowed_shells,omitempty" yaml:"allowed_shells,omitempty"`
+
+	// Sandbox – OCI execution backend
+	// ExecBackend selects how shell_exec runs commands: "host" (exec.CommandContext,
+	// the historical behavior) or "oci" (isolate each command in an OCI-spec
+	// container via an external runtime such as runc or crun).
+	ExecBackend      string `json:"exec_backend" yaml:"exec_backend"`
+	OCIRuntimePath   string `json:"oci_runtime_path,omitempty" yaml:"oci_runtime_path,omitempty"` // path to runc/crun; auto-detected if empty
+	OCIMemoryLimitMB int64  `json:"oci_memory_limit_mb" yaml:"oci_memory_limit_mb"`
+	OCICPUQuotaPct   int    `json:"oci_cpu_quota_pct" yaml:"oci_cpu_quota_pct"` // percentage of one CPU, e.g. 100 = 1 core
+	OCIPidsLimit     int64  `json:"oci_pids_limit" yaml:"oci_pids_limit"`
+	// OCIRequireRuntime fails a shell_exec call rather than silently
+	// dropping to the unisolated host backend when ExecBackend is "oci" but
+	// no runc/crun can be found. Defaults to true: an operator who asked
+	// for OCI isolation should find out immediately if it stopped working,
+	// not discover it later from an incident.
+	OCIRequireRuntime bool `json:"oci_require_runtime" yaml:"oci_require_runtime"`
 
 	// Logging
-	LogLevel string `json:"log_level"`
+	LogLevel string `json:"log_level" yaml:"log_level"`
+	// LogFormat selects the encoding of stderr-bound log records: "text"
+	// (default, human-readable) or "json" (structured, for shipping to log
+	// aggregators). It does not affect "file:"/"journald"/"syslog" sinks,
+	// which have their own fixed encodings.
+	LogFormat string `json:"log_format" yaml:"log_format"`
+	// LogSinks lists where log records are sent: "stderr", "syslog://host:514"
+	// (or "syslog+tcp://..."/"syslog+unix:///path"), "journald", and/or
+	// "file:/path/to/file.log" (size-based rotation; see internal/logging).
+	// Defaults to ["stderr"] when empty.
+	LogSinks []string `json:"log_sinks,omitempty" yaml:"log_sinks,omitempty"`
+	// LogRotateMaxMB and LogRotateMaxFiles configure every "file:" sink in
+	// LogSinks: rotate once the active file reaches this size, keeping this
+	// many files in total – the active file plus LogRotateMaxFiles-1 rotated
+	// ones (oldest deleted first). Applies process-wide rather
+	// than per-sink since the agent only ever has one or two file sinks.
+	LogRotateMaxMB    int `json:"log_rotate_max_mb" yaml:"log_rotate_max_mb"`
+	LogRotateMaxFiles int `json:"log_rotate_max_files" yaml:"log_rotate_max_files"`
+
+	// MetricsAddr, when non-empty, starts a local HTTP listener (e.g.
+	// "127.0.0.1:9090") serving Prometheus metrics at /metrics and a
+	// connection health check at /healthz. Disabled by default.
+	MetricsAddr string `json:"metrics_addr,omitempty" yaml:"metrics_addr,omitempty"`
+
+	// Tools holds optional per-tool-group overrides, keyed by group name
+	// ("fs", "shell"). A group absent from this map is enabled and uses the
+	// top-level defaults above (WorkRoot, AllowedShells/BlockedCommands) and
+	// the process-wide logger built from LogLevel and LogSinks.
+	Tools map[string]ToolConfig `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// DiscoveryConfig selects and configures how the agent resolves its
+// tgo-device-control endpoint; see internal/discovery.Resolver.
+type DiscoveryConfig struct {
+	// Mode is "static" (default), "dns-srv", "nats-kv", or "mdns". Only
+	// "static" and "dns-srv" are implemented so far; the other two are
+	// accepted here as reserved extension points.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Domain is the DNS zone dns-srv resolves "_tgo._tcp.<domain>" SRV
+	// records under. Required when Mode is "dns-srv".
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+}
+
+// ToolConfig is the per-tool-group subsection of Tools. Its zero value
+// (Enabled: false) would disable a group, so callers should check for the
+// group's absence from Config.Tools rather than relying on the zero value –
+// see Config.ToolGroup.
+type ToolConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// LogLevel and LogTarget set the level and target of just this group's
+	// logger. LogTarget is one of "stderr", "file:/path/to/file.log", or
+	// "json" (structured stderr). An empty LogLevel inherits the top-level
+	// LogLevel; an empty LogTarget means "stderr" (the top-level LogSinks are
+	// not inherited).
+	LogLevel  string `json:"log_level,omitempty" yaml:"log_level,omitempty"`
+	LogTarget string `json:"log_target,omitempty" yaml:"log_target,omitempty"`
+
+	// WorkRoot overrides the top-level WorkRoot for the fs tool group only.
+	// Empty means "use Config.WorkRoot".
+	WorkRoot string `json:"work_root,omitempty" yaml:"work_root,omitempty"`
+
+	// AllowedShells and BlockedCommands override the top-level lists of the
+	// same name for the shell tool group only. Empty means "use the
+	// top-level list".
+	AllowedShells   []string `json:"allowed_shells,omitempty" yaml:"allowed_shells,omitempty"`
+	BlockedCommands []string `json:"blocked_commands,omitempty" yaml:"blocked_commands,omitempty"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -57,26 +168,60 @@ func DefaultConfig() *Config {
 	return &Config{
 		ServerHost:            "localhost",
 		ServerPort:            9876,
+		Transport:             "tcp",
 		TokenFile:             tokenFile,
 		DeviceName:            hostname(),
 		ReconnectInitialDelay: 1 * time.Second,
 		ReconnectMaxDelay:     30 * time.Second,
 		MaxReconnectAttempts:  0,
 		HeartbeatInterval:     25 * time.Second,
+		WriteTimeout:          10 * time.Second,
 		WorkRoot:              ".",
 		MaxReadBytes:          10 * 1024 * 1024, // 10 MB
 		MaxWriteBytes:         10 * 1024 * 1024,
+		MaxJournalBytes:       50 * 1024 * 1024, // 50 MB
 		ShellTimeout:          60 * time.Second,
 		MaxOutputBytes:        1 * 1024 * 1024, // 1 MB
 		BlockedCommands: []string{
 			"rm -rf /", "mkfs", "dd if=/dev/zero",
 			":(){:|:&};:", "fork bomb",
 		},
-		AllowedShells: []string{"/bin/sh", "/bin/bash", "/bin/zsh"},
-		LogLevel:      "info",
+		AllowedShells:     []string{"/bin/sh", "/bin/bash", "/bin/zsh"},
+		ExecBackend:       detectExecBackend(),
+		OCIMemoryLimitMB:  512,
+		OCICPUQuotaPct:    100,
+		OCIPidsLimit:      64,
+		OCIRequireRuntime: true,
+		LogLevel:          "info",
+		LogFormat:         "text",
+		LogRotateMaxMB:    100,
+		LogRotateMaxFiles: 5,
 	}
 }
 
+// detectExecBackend picks "oci" when a container runtime is available on
+// $PATH, falling back to the raw-exec "host" backend otherwise. Either can
+// still be overridden explicitly via config or the ExecBackend field.
+func detectExecBackend() string {
+	for _, rt := range []string{"runc", "crun"} {
+		if _, err := exec.LookPath(rt); err == nil {
+			return "oci"
+		}
+	}
+	return "host"
+}
+
+// ToolGroup looks up group (e.g. "fs", "shell") in Tools, returning a
+// ToolConfig with Enabled true when the group isn't listed at all – an
+// absent group means "use the defaults", not "disabled".
+func (c *Config) ToolGroup(group string) ToolConfig {
+	tc, ok := c.Tools[group]
+	if !ok {
+		return ToolConfig{Enabled: true}
+	}
+	return tc
+}
+
 // LoadTokenFromFile reads a saved device token from disk.
 func (c *Config) LoadTokenFromFile() {
 	data, err := os.ReadFile(c.TokenFile)
@@ -95,13 +240,37 @@ func (c *Config) SaveTokenToFile(token string) error {
 	return os.WriteFile(c.TokenFile, []byte(token), 0o600)
 }
 
-// LoadFromFile reads JSON config from the given path and merges into c.
+// DefaultConfigFile returns the on-disk path the "config" CLI subcommand
+// reads and writes when --config isn't given.
+func DefaultConfigFile() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".tgo-device-agent", "config.json")
+}
+
+// OperatorPromptSocketPath returns where the operator-auth prompt listens
+// for headless installs with no controlling TTY (see auth.Confirm). It
+// lives alongside the device token rather than behind its own config field,
+// since it's derived, host-local state rather than something operators need
+// to tune.
+func (c *Config) OperatorPromptSocketPath() string {
+	return filepath.Join(filepath.Dir(c.TokenFile), "operator-auth.sock")
+}
+
+// LoadFromFile reads config from the given path and merges it into c. The
+// format is chosen by extension: ".yaml"/".yml" parses as YAML, anything
+// else (including ".json" and no extension) parses as JSON.
 func (c *Config) LoadFromFile(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, c)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, c)
+	default:
+		return json.Unmarshal(data, c)
+	}
 }
 
 func hostname() string {
@@ -0,0 +1,63 @@
+// Package discovery resolves the tgo-device-control endpoint the transport
+// client connects to, so a deployment can point the agent at a service
+// registry instead of a single hardcoded host:port. A Resolver supplies the
+// current best endpoint plus a change notification the client's reconnect
+// loop can use to rebalance as soon as its current endpoint disappears,
+// rather than waiting to notice over a dead connection.
+package discovery
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+)
+
+// Endpoint is a candidate tgo-device-control address.
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+func (e Endpoint) String() string {
+	return fmt.Sprintf("%s:%d", e.Host, e.Port)
+}
+
+// Resolver supplies candidate endpoints to transport.Client.
+type Resolver interface {
+	// Current returns the best endpoint to (re)connect to right now.
+	Current(ctx context.Context) (Endpoint, error)
+
+	// Watch returns a channel that receives a value whenever the resolver
+	// believes the client should reconnect immediately – typically because
+	// the endpoint currently in use has dropped out of the registry.
+	// Implementations with nothing to watch (static) may return nil, which
+	// simply never fires.
+	Watch(ctx context.Context) <-chan struct{}
+}
+
+// New builds the Resolver configured by cfg.Discovery.Mode:
+//
+//   - "" or "static" – cfg.ServerHost/cfg.ServerPort, never changes (the
+//     agent's original, and still default, behavior)
+//   - "dns-srv"      – resolves _tgo._tcp.<cfg.Discovery.Domain> SRV records
+//
+// "nats-kv" and "mdns" are recognized by --discovery but not yet
+// implemented; a Resolver for either can be added here without touching any
+// caller, since everything downstream only depends on the Resolver
+// interface above.
+func New(cfg *config.Config) (Resolver, error) {
+	switch cfg.Discovery.Mode {
+	case "", "static":
+		return NewStatic(cfg.ServerHost, cfg.ServerPort), nil
+	case "dns-srv":
+		if cfg.Discovery.Domain == "" {
+			return nil, fmt.Errorf("discovery: dns-srv mode requires discovery.domain to be set")
+		}
+		return newDNSSRV(cfg.Discovery.Domain), nil
+	case "nats-kv", "mdns":
+		return nil, fmt.Errorf("discovery: mode %q is recognized but not yet implemented", cfg.Discovery.Mode)
+	default:
+		return nil, fmt.Errorf("discovery: unknown mode %q", cfg.Discovery.Mode)
+	}
+}
@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dnsSRVPollInterval is how often the background Watch goroutine re-resolves
+// to notice a changed endpoint. DNS has no native push/watch mechanism, so
+// polling is the best this resolver can do.
+const dnsSRVPollInterval = 30 * time.Second
+
+// dnsSRVResolver resolves "_tgo._tcp.<domain>" SRV records, selecting among
+// them per RFC 2782 (lowest Priority wins; ties broken by weighted random
+// choice among Weight). lookupSRV is overridable for testing.
+type dnsSRVResolver struct {
+	domain    string
+	lookupSRV func(service, proto, name string) (string, []*net.SRV, error)
+
+	mu      sync.Mutex
+	current Endpoint
+}
+
+func newDNSSRV(domain string) *dnsSRVResolver {
+	return &dnsSRVResolver{domain: domain, lookupSRV: net.LookupSRV}
+}
+
+func (r *dnsSRVResolver) Current(_ context.Context) (Endpoint, error) {
+	_, srvs, err := r.lookupSRV("tgo", "tcp", r.domain)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("resolve _tgo._tcp.%s: %w", r.domain, err)
+	}
+	if len(srvs) == 0 {
+		return Endpoint{}, fmt.Errorf("no SRV records for _tgo._tcp.%s", r.domain)
+	}
+
+	best := pickSRV(srvs)
+	ep := Endpoint{Host: strings.TrimSuffix(best.Target, "."), Port: int(best.Port)}
+
+	r.mu.Lock()
+	r.current = ep
+	r.mu.Unlock()
+	return ep, nil
+}
+
+// Watch polls the SRV record set every dnsSRVPollInterval and fires once
+// whenever the best endpoint changes from the one last returned by Current.
+func (r *dnsSRVResolver) Watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(dnsSRVPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.mu.Lock()
+				prev := r.current
+				r.mu.Unlock()
+
+				next, err := r.Current(ctx)
+				if err != nil || next == prev {
+					continue
+				}
+				select {
+				case ch <- struct{}{}:
+				default: // already pending; the reader hasn't caught up yet
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// pickSRV chooses one record per RFC 2782 §"Usage rules": among the
+// lowest-Priority records, pick randomly with probability proportional to
+// Weight (a Weight of 0 is only picked when it's the sole candidate left).
+func pickSRV(srvs []*net.SRV) *net.SRV {
+	lowest := srvs[0].Priority
+	for _, s := range srvs[1:] {
+		if s.Priority < lowest {
+			lowest = s.Priority
+		}
+	}
+
+	var candidates []*net.SRV
+	var totalWeight int
+	for _, s := range srvs {
+		if s.Priority == lowest {
+			candidates = append(candidates, s)
+			totalWeight += int(s.Weight)
+		}
+	}
+	if len(candidates) == 1 || totalWeight == 0 {
+		return candidates[0]
+	}
+
+	pick := rand.Intn(totalWeight)
+	for _, s := range candidates {
+		pick -= int(s.Weight)
+		if pick < 0 {
+			return s
+		}
+	}
+	return candidates[len(candidates)-1]
+}
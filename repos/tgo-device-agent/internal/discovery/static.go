@@ -0,0 +1,25 @@
+package discovery
+
+import "context"
+
+// staticResolver always returns the same endpoint – the agent's original
+// behavior before discovery existed, and still the default.
+type staticResolver struct {
+	endpoint Endpoint
+}
+
+// NewStatic returns a Resolver that always returns host:port, exported so
+// callers needing a guaranteed-valid fallback Resolver (e.g. transport.Client
+// when none is supplied) don't have to go through New and its Discovery.Mode
+// dispatch.
+func NewStatic(host string, port int) Resolver {
+	return &staticResolver{endpoint: Endpoint{Host: host, Port: port}}
+}
+
+func (r *staticResolver) Current(_ context.Context) (Endpoint, error) {
+	return r.endpoint, nil
+}
+
+func (r *staticResolver) Watch(_ context.Context) <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,299 @@
+// Package journal records a compact, bounded history of every mutation
+// fs_write and fs_edit make, so a controller can inspect recent changes
+// (fs_history) and undo one entry or a whole request (fs_revert) without
+// depending on the target machine having git.
+package journal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/sandbox"
+)
+
+// dirName is the journal's location under the sandbox work root, alongside
+// any other agent-private state.
+const dirName = ".tgo/journal"
+
+// Entry is one recorded mutation: the file's content immediately before
+// the tool ran, plus enough metadata to list and restore it.
+type Entry struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`      // "fs_write" or "fs_edit"
+	Path      string    `json:"path"`      // resolved, absolute path
+	RequestID string    `json:"requestId"` // originating JSON-RPC request/call ID
+	Timestamp time.Time `json:"timestamp"`
+	SHA256    string    `json:"sha256"` // of the original (pre-mutation) content
+	Existed   bool      `json:"existed"`   // false if the tool created path
+	Size      int64     `json:"size"`      // original content size, uncompressed
+
+	// Data holds the gzip-compressed original content. Omitted from
+	// listings (see List) since it's only needed to actually restore path.
+	Data []byte `json:"data,omitempty"`
+}
+
+// Journal is a bounded, append-only ring of Entry records, persisted as one
+// JSON file per entry under <WorkRoot>/.tgo/journal. Oldest entries are
+// evicted first once the ring exceeds maxBytes.
+type Journal struct {
+	dir      string
+	maxBytes int64
+}
+
+var seq atomic.Int64
+
+// New creates a Journal rooted at sb's work root, creating its directory
+// if necessary.
+func New(sb *sandbox.Sandbox, cfg *config.Config) (*Journal, error) {
+	dir := filepath.Join(sb.WorkRoot(), dirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create journal dir: %w", err)
+	}
+	return &Journal{dir: dir, maxBytes: cfg.MaxJournalBytes}, nil
+}
+
+// Record compresses and persists original – the content of path immediately
+// before a tool mutates it – then evicts the oldest entries until the ring
+// fits within maxBytes. existed is false when the tool is creating path
+// rather than overwriting or deleting it.
+func (j *Journal) Record(tool, path, requestID string, original []byte, existed bool) (*Entry, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(original); err != nil {
+		return nil, fmt.Errorf("compress journal entry: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("compress journal entry: %w", err)
+	}
+
+	sum := sha256.Sum256(original)
+	entry := &Entry{
+		ID:        entryID(),
+		Tool:      tool,
+		Path:      path,
+		RequestID: requestID,
+		Timestamp: time.Now().UTC(),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Existed:   existed,
+		Size:      int64(len(original)),
+		Data:      buf.Bytes(),
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("marshal journal entry: %w", err)
+	}
+	if err := os.WriteFile(j.entryPath(entry.ID), data, 0o600); err != nil {
+		return nil, fmt.Errorf("write journal entry: %w", err)
+	}
+
+	j.evict()
+	return entry, nil
+}
+
+// List returns recorded entries, most recent first, optionally filtered to
+// those whose Path exactly matches pathFilter, capped at limit (0 means
+// unlimited). Entries are returned without their Data payload; use Get to
+// fetch the full entry before reverting.
+func (j *Journal) List(pathFilter string, limit int) ([]Entry, error) {
+	names, err := j.sortedEntryNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Entry
+	for i := len(names) - 1; i >= 0; i-- {
+		entry, err := j.readEntry(names[i])
+		if err != nil {
+			continue // skip a corrupt entry rather than fail the whole listing
+		}
+		if pathFilter != "" && entry.Path != pathFilter {
+			continue
+		}
+		entry.Data = nil
+		out = append(out, *entry)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// Get returns the full entry (including its compressed payload) for id.
+func (j *Journal) Get(id string) (*Entry, error) {
+	entry, err := j.readEntry(entryFileName(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("journal entry %q not found", id)
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Revert restores the file recorded by entry id to its pre-mutation state
+// (or removes it, if the tool created it), after checking the write
+// against sb's policy.
+func (j *Journal) Revert(ctx context.Context, id string, sb *sandbox.Sandbox) (*Entry, error) {
+	entry, err := j.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := j.restore(ctx, entry, sb); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RevertRequest restores every file mutated by requestID, newest entry
+// first so that a request which touched the same path more than once is
+// unwound in the right order. It returns the paths restored.
+func (j *Journal) RevertRequest(ctx context.Context, requestID string, sb *sandbox.Sandbox) ([]string, error) {
+	names, err := j.sortedEntryNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*Entry
+	for i := len(names) - 1; i >= 0; i-- {
+		entry, err := j.readEntry(names[i])
+		if err != nil {
+			continue
+		}
+		if entry.RequestID == requestID {
+			matched = append(matched, entry)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no journal entries found for request %q", requestID)
+	}
+
+	var restored []string
+	for _, entry := range matched {
+		if err := j.restore(ctx, entry, sb); err != nil {
+			return restored, fmt.Errorf("revert %s: %w", entry.Path, err)
+		}
+		restored = append(restored, entry.Path)
+	}
+	return restored, nil
+}
+
+// restore writes entry's original content back to entry.Path, or removes
+// path entirely if the tool had created it.
+func (j *Journal) restore(ctx context.Context, entry *Entry, sb *sandbox.Sandbox) error {
+	if err := sb.ValidateWrite(ctx, entry.Path); err != nil {
+		return err
+	}
+
+	if !entry.Existed {
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", entry.Path, err)
+		}
+		return nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(entry.Data))
+	if err != nil {
+		return fmt.Errorf("decompress journal entry: %w", err)
+	}
+	defer gz.Close()
+	original, err := io.ReadAll(gz)
+	if err != nil {
+		return fmt.Errorf("decompress journal entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0o755); err != nil {
+		return fmt.Errorf("create directories for %s: %w", entry.Path, err)
+	}
+	return os.WriteFile(entry.Path, original, 0o644)
+}
+
+// evict removes the oldest entries until the ring's total size fits within
+// maxBytes. A non-positive maxBytes disables eviction.
+func (j *Journal) evict() {
+	if j.maxBytes <= 0 {
+		return
+	}
+	names, err := j.sortedEntryNames()
+	if err != nil {
+		return
+	}
+
+	type sizedEntry struct {
+		name string
+		size int64
+	}
+	sized := make([]sizedEntry, 0, len(names))
+	var total int64
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(j.dir, name))
+		if err != nil {
+			continue
+		}
+		sized = append(sized, sizedEntry{name, info.Size()})
+		total += info.Size()
+	}
+
+	for total > j.maxBytes && len(sized) > 0 {
+		oldest := sized[0]
+		sized = sized[1:]
+		if err := os.Remove(filepath.Join(j.dir, oldest.name)); err == nil {
+			total -= oldest.size
+		}
+	}
+}
+
+func (j *Journal) sortedEntryNames() ([]string, error) {
+	de, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read journal dir: %w", err)
+	}
+	names := make([]string, 0, len(de))
+	for _, e := range de {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names) // entry IDs are zero-padded, so lexical order == chronological
+	return names, nil
+}
+
+func (j *Journal) readEntry(name string) (*Entry, error) {
+	data, err := os.ReadFile(filepath.Join(j.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("parse journal entry %q: %w", name, err)
+	}
+	return &entry, nil
+}
+
+func (j *Journal) entryPath(id string) string {
+	return filepath.Join(j.dir, entryFileName(id))
+}
+
+func entryFileName(id string) string {
+	return id + ".json"
+}
+
+// entryID returns a lexically-sortable, unique entry ID: a zero-padded
+// nanosecond timestamp plus a monotonic counter to break ties within the
+// same nanosecond.
+func entryID() string {
+	return fmt.Sprintf("%020d-%06d", time.Now().UnixNano(), seq.Add(1))
+}
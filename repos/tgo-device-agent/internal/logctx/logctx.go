@@ -0,0 +1,52 @@
+// Package logctx carries request-scoped log fields (a correlation/call ID,
+// an optional server-provided trace ID, and anything else a caller wants to
+// attach) on a context.Context, so code several layers deep can log with
+// that baggage without threading it through every function signature. It
+// pairs with internal/logging, whose handlers read the fields back out in
+// Handle so they land on every record automatically.
+package logctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// fieldsKey is the context key under which baggage fields are stored.
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying args (alternating key/value
+// pairs, or slog.Attr values, exactly as accepted by slog.Logger.With)
+// appended to whatever fields ctx already carries, so nested calls add to
+// the baggage instead of replacing it.
+func WithFields(ctx context.Context, args ...any) context.Context {
+	if len(args) == 0 {
+		return ctx
+	}
+	existing := FieldsFromContext(ctx)
+	merged := make([]any, 0, len(existing)+len(args))
+	merged = append(merged, existing...)
+	merged = append(merged, args...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext returns the baggage fields stashed by WithFields, or
+// nil if ctx carries none. The result is suitable for slog.Record.Add or
+// slog.Logger.With.
+func FieldsFromContext(ctx context.Context) []any {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	return fields
+}
+
+// LoggerFromContext returns slog.Default() with ctx's baggage fields
+// already attached via With. It's for code that logs without a
+// context-aware handler in its chain (e.g. a goroutine that outlives the
+// request); callers with a context-aware handler installed (see
+// internal/logging) can keep using *Context logger methods directly and
+// get the same fields for free.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return slog.Default()
+	}
+	return slog.Default().With(fields...)
+}
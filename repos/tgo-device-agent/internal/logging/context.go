@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/tgoai/tgo-device-agent/internal/logctx"
+)
+
+// contextHandler wraps a slog.Handler, adding any baggage fields stashed on
+// the context (see internal/logctx.WithFields) to every record before
+// delegating. This is what lets a correlation ID attached once in
+// transport.Client show up on every downstream log line without the
+// intervening tool code passing it around by hand.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if fields := logctx.FieldsFromContext(ctx); len(fields) > 0 {
+		r.Add(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
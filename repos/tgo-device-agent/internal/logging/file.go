@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile is an io.Writer over a single log file that rotates once the
+// file reaches maxBytes, keeping at most maxFiles total (the active file
+// plus maxFiles-1 rotated ones, named path.1 (newest) through path.N-1
+// (oldest); anything older is deleted).
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// newRotatingFile opens (or creates) path for appending and returns a
+// rotatingFile ready to write to it.
+func newRotatingFile(path string, maxMB, maxFiles int) (*rotatingFile, error) {
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat log file: %w", err)
+	}
+
+	return &rotatingFile{
+		path:     path,
+		maxBytes: int64(maxMB) * 1024 * 1024,
+		maxFiles: maxFiles,
+		f:        f,
+		size:     info.Size(),
+	}, nil
+}
+
+// Write implements io.Writer, rotating first if p would push the active
+// file past maxBytes.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxBytes > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotateLocked(); err != nil {
+			// A failed rotation shouldn't take logging down entirely;
+			// keep writing to the oversized file instead.
+			fmt.Fprintf(os.Stderr, "logging: failed to rotate %s: %v\n", r.path, err)
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotateLocked closes the active file, shifts path.1..path.N-1 (the
+// rotated backups, N = r.maxFiles) up by one slot (dropping whatever was in
+// the oldest one), moves the just-closed active file into path.1, and opens
+// a fresh, empty file at path. Caller must hold r.mu.
+func (r *rotatingFile) rotateLocked() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+
+	if r.maxFiles <= 1 {
+		// No rotated backups are retained; the old active file is simply
+		// discarded rather than kept as path.1.
+		os.Remove(r.path)
+	} else {
+		os.Remove(r.rotatedPath(r.maxFiles - 1))
+		for i := r.maxFiles - 2; i >= 1; i-- {
+			src := r.rotatedPath(i)
+			if _, err := os.Stat(src); err == nil {
+				os.Rename(src, r.rotatedPath(i+1))
+			}
+		}
+		if err := os.Rename(r.path, r.rotatedPath(1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	r.f = f
+	r.size = 0
+	return nil
+}
+
+func (r *rotatingFile) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
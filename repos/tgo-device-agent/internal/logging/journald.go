@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// journaldSocketPath is systemd-journald's native datagram socket.
+const journaldSocketPath = "/run/systemd/journal/socket"
+
+// journaldHandler ships records to the local systemd-journald daemon over
+// its native socket, one newline-separated FIELD=value entry per record.
+type journaldHandler struct {
+	level slog.Level
+	attrs []slog.Attr
+	conn  *sinkConn
+}
+
+func newJournaldHandler(level slog.Level) (slog.Handler, error) {
+	conn, err := newSinkConn("journald", func() (net.Conn, error) {
+		return net.Dial("unixgram", journaldSocketPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldHandler{level: level, conn: conn}, nil
+}
+
+func (h *journaldHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *journaldHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	writeField(&b, "MESSAGE", r.Message)
+	writeField(&b, "PRIORITY", strconv.Itoa(severityFor(r.Level)))
+	for _, a := range h.attrs {
+		writeField(&b, journaldFieldName(a.Key), fmt.Sprint(a.Value.Any()))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeField(&b, journaldFieldName(a.Key), fmt.Sprint(a.Value.Any()))
+		return true
+	})
+
+	h.conn.write([]byte(b.String()))
+	return nil
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &next
+}
+
+func (h *journaldHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+// writeField appends one "NAME=value\n" entry, using journald's simple
+// newline-terminated form. Embedded newlines in value are flattened to
+// spaces rather than switching to journald's binary length-prefixed form,
+// which is more than this best-effort sink needs.
+func writeField(b *strings.Builder, name, value string) {
+	fmt.Fprintf(b, "%s=%s\n", name, strings.ReplaceAll(value, "\n", " "))
+}
+
+// journaldFieldName uppercases key and replaces any character journald
+// doesn't allow in a field name with '_'.
+func journaldFieldName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if r == '_' || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
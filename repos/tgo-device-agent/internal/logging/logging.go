@@ -0,0 +1,213 @@
+// Package logging wires log/slog to optional additional sinks – RFC 5424
+// syslog and systemd-journald – alongside the default stderr handler, so
+// operators can aggregate agent activity across a fleet in their existing
+// log infrastructure instead of scraping stderr. Every handler it builds is
+// wrapped in contextHandler, which reads the correlation-ID baggage set by
+// internal/logctx.WithFields so those fields land on every record without
+// callers threading them through explicitly.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+)
+
+// Setup builds the process-wide logger from cfg.LogLevel and cfg.LogSinks
+// and installs it as slog.Default(). Each entry in cfg.LogSinks is one of:
+//
+//   - "stderr"                     – text (or JSON, per cfg.LogFormat) to stderr
+//   - "syslog://host:514"          – RFC 5424 syslog over UDP
+//   - "syslog+tcp://host:514"      – RFC 5424 syslog over TCP
+//   - "syslog+unix:///dev/log"     – RFC 5424 syslog over a UNIX socket
+//   - "journald"                   – systemd-journald's native socket
+//
+// An empty cfg.LogSinks defaults to ["stderr"]. A sink that fails to open
+// (a bad address, journald not running, etc.) is reported to stderr and
+// skipped rather than stopping the agent from starting; if every sink
+// fails to open, stderr logging is forced on so the agent is never silent.
+func Setup(cfg *config.Config) error {
+	level := parseLevel(cfg.LogLevel)
+	sinks := cfg.LogSinks
+	if len(sinks) == 0 {
+		sinks = []string{"stderr"}
+	}
+
+	handlers := make([]slog.Handler, 0, len(sinks))
+	for _, sink := range sinks {
+		h, err := buildHandler(sink, level, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logging: skipping sink %q: %v\n", sink, err)
+			continue
+		}
+		handlers = append(handlers, h)
+	}
+	if len(handlers) == 0 {
+		handlers = append(handlers, newStderrHandler(level))
+	}
+
+	slog.SetDefault(slog.New(contextHandler{fanoutHandler{handlers: handlers}}))
+	return nil
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func buildHandler(sink string, level slog.Level, cfg *config.Config) (slog.Handler, error) {
+	switch {
+	case sink == "" || sink == "stderr":
+		return stderrHandler(level, cfg), nil
+	case sink == "journald":
+		return newJournaldHandler(level)
+	case strings.HasPrefix(sink, "syslog"):
+		return newSyslogHandler(sink, level)
+	case strings.HasPrefix(sink, "file:"):
+		path := strings.TrimPrefix(sink, "file:")
+		rf, err := newRotatingFile(path, cfg.LogRotateMaxMB, cfg.LogRotateMaxFiles)
+		if err != nil {
+			return nil, fmt.Errorf("open log file %q: %w", path, err)
+		}
+		return slog.NewTextHandler(rf, &slog.HandlerOptions{Level: level}), nil
+	default:
+		return nil, fmt.Errorf("unknown log sink %q", sink)
+	}
+}
+
+// SetupLoggers builds one *slog.Logger per entry in cfg.Tools (the per-tool
+// group sections added alongside Tools), keyed by group name. A group that
+// doesn't set log_level/log_target inherits cfg.LogLevel and the "stderr"
+// target. Tool groups absent from cfg.Tools get no entry here at all;
+// LoggerFor falls back to slog.Default() for those, which is what every
+// group used before this existed.
+func SetupLoggers(cfg *config.Config) (map[string]*slog.Logger, error) {
+	loggers := make(map[string]*slog.Logger, len(cfg.Tools))
+	for group, tc := range cfg.Tools {
+		level := cfg.LogLevel
+		if tc.LogLevel != "" {
+			level = tc.LogLevel
+		}
+		target := tc.LogTarget
+		if target == "" {
+			target = "stderr"
+		}
+
+		h, err := buildToolHandler(target, parseLevel(level), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("tool group %q: %w", group, err)
+		}
+		loggers[group] = slog.New(contextHandler{h})
+	}
+	return loggers, nil
+}
+
+// LoggerFor returns loggers[group], or slog.Default() if group has no
+// dedicated logger – the common case, since most deployments never set a
+// per-tool-group log_target.
+func LoggerFor(loggers map[string]*slog.Logger, group string) *slog.Logger {
+	if l, ok := loggers[group]; ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// buildToolHandler builds the slog.Handler for one tool group's log_target:
+// "stderr" (the default), "json" (structured stderr), or
+// "file:/path/to/file.log" (rotated per cfg.LogRotateMaxMB/LogRotateMaxFiles).
+func buildToolHandler(target string, level slog.Level, cfg *config.Config) (slog.Handler, error) {
+	switch {
+	case target == "stderr":
+		return stderrHandler(level, cfg), nil
+	case target == "json":
+		return slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}), nil
+	case strings.HasPrefix(target, "file:"):
+		path := strings.TrimPrefix(target, "file:")
+		rf, err := newRotatingFile(path, cfg.LogRotateMaxMB, cfg.LogRotateMaxFiles)
+		if err != nil {
+			return nil, fmt.Errorf("open log file %q: %w", path, err)
+		}
+		return slog.NewTextHandler(rf, &slog.HandlerOptions{Level: level}), nil
+	default:
+		return nil, fmt.Errorf("unknown log target %q", target)
+	}
+}
+
+// stderrHandler picks the stderr handler for level per cfg.LogFormat:
+// "json" (structured, for shipping to log aggregators) or the default
+// human-readable text format.
+func stderrHandler(level slog.Level, cfg *config.Config) slog.Handler {
+	if cfg.LogFormat == "json" {
+		return slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	}
+	return newStderrHandler(level)
+}
+
+func newStderrHandler(level slog.Level) slog.Handler {
+	return slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				a.Value = slog.StringValue(time.Now().Format("15:04:05.000"))
+			}
+			return a
+		},
+	})
+}
+
+// fanoutHandler dispatches every record to each of handlers, so Setup can
+// fan a single logger out to stderr plus any number of remote sinks.
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range f.handlers {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make([]slog.Handler, len(f.handlers))
+	for i, h := range f.handlers {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return fanoutHandler{handlers: next}
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	next := make([]slog.Handler, len(f.handlers))
+	for i, h := range f.handlers {
+		next[i] = h.WithGroup(name)
+	}
+	return fanoutHandler{handlers: next}
+}
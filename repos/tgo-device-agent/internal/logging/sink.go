@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// sinkReconnectInterval bounds how often a down sinkConn retries dialing,
+// and how often it re-warns on stderr about messages it has dropped.
+const sinkReconnectInterval = 10 * time.Second
+
+// sinkConn manages a best-effort connection to a remote or local log sink.
+// Writes never block the caller on a down sink: they're dropped, a
+// reconnect is attempted at most once per sinkReconnectInterval, and the
+// "messages dropped" warning to stderr is rate-limited to the same
+// interval so a flapping sink can't spam stderr either.
+type sinkConn struct {
+	name string // for stderr warnings, e.g. "syslog udp 10.0.0.1:514"
+	dial func() (net.Conn, error)
+
+	mu         sync.Mutex
+	conn       net.Conn
+	nextRetry  time.Time
+	lastWarnAt time.Time
+	dropped    int64
+}
+
+// newSinkConn dials once up front so Setup can report a bad sink
+// immediately instead of only discovering it on the first dropped record.
+func newSinkConn(name string, dial func() (net.Conn, error)) (*sinkConn, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	return &sinkConn{name: name, dial: dial, conn: conn}, nil
+}
+
+func (s *sinkConn) write(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		s.maybeReconnectLocked()
+		if s.conn == nil {
+			s.dropLocked()
+			return
+		}
+	}
+	if _, err := s.conn.Write(data); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.dropLocked()
+	}
+}
+
+func (s *sinkConn) maybeReconnectLocked() {
+	if time.Now().Before(s.nextRetry) {
+		return
+	}
+	conn, err := s.dial()
+	if err != nil {
+		s.nextRetry = time.Now().Add(sinkReconnectInterval)
+		return
+	}
+	s.conn = conn
+}
+
+func (s *sinkConn) dropLocked() {
+	s.dropped++
+	if time.Since(s.lastWarnAt) < sinkReconnectInterval {
+		return
+	}
+	s.lastWarnAt = time.Now()
+	fmt.Fprintf(os.Stderr, "logging: %s unreachable, dropped %d message(s) so far\n", s.name, s.dropped)
+}
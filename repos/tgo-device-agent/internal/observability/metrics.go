@@ -0,0 +1,28 @@
+// Package observability exposes the agent's internal state as Prometheus
+// metrics and a /healthz endpoint over an optional local HTTP listener, so
+// existing monitoring can scrape the agent the same way it scrapes any
+// other service.
+package observability
+
+import "time"
+
+// Metrics is the subset of instrumentation that tools.Registry and
+// transport.Client need. It's defined here, rather than having those
+// packages import Prometheus directly, so only Recorder (and whatever
+// constructs it) takes a Prometheus dependency.
+type Metrics interface {
+	// ToolCall records one completed tool invocation: its name and result
+	// status ("ok" or "error").
+	ToolCall(tool, status string)
+	// ReconnectAttempt records one transport reconnect attempt.
+	ReconnectAttempt()
+	// SetConnected reports whether the transport is currently connected
+	// and authenticated. It backs /healthz.
+	SetConnected(connected bool)
+	// SetTokenAge reports how long the current device token has been in
+	// use, so token rotation can be monitored externally.
+	SetTokenAge(age time.Duration)
+	// SetSupervisorState reports the supervisor's current lifecycle state
+	// (one of "starting", "running", "backoff", "fatal", "stopped").
+	SetSupervisorState(state string)
+}
@@ -0,0 +1,109 @@
+package observability
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Recorder is the Prometheus-backed implementation of Metrics.
+type Recorder struct {
+	registry *prometheus.Registry
+
+	toolCalls         *prometheus.CounterVec
+	reconnectAttempts prometheus.Counter
+	connected         prometheus.Gauge
+	tokenAgeSeconds   prometheus.Gauge
+	supervisorState   *prometheus.GaugeVec
+
+	connectedState atomic.Bool
+}
+
+// supervisorStates lists every value SetSupervisorState accepts, so the
+// gauge for the previous state can be zeroed when a new one is set.
+var supervisorStates = []string{"starting", "running", "backoff", "fatal", "stopped"}
+
+// NewRecorder creates a Recorder on its own Prometheus registry, rather than
+// the global DefaultRegisterer, so registering it can never collide with
+// something else in the process also importing client_golang. version/goos/
+// goarch populate a build-info gauge, mirroring the convention of labeling
+// an always-1 gauge with build metadata instead of exposing it as a string.
+func NewRecorder(version, goos, goarch string) *Recorder {
+	reg := prometheus.NewRegistry()
+
+	r := &Recorder{
+		registry: reg,
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tgo_agent_tool_calls_total",
+			Help: "Total tool invocations, by tool name and result status.",
+		}, []string{"tool", "status"}),
+		reconnectAttempts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tgo_agent_reconnect_attempts_total",
+			Help: "Total transport reconnect attempts.",
+		}),
+		connected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tgo_agent_connected",
+			Help: "1 if the transport is currently connected and authenticated, 0 otherwise.",
+		}),
+		tokenAgeSeconds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tgo_agent_token_age_seconds",
+			Help: "Age, in seconds, of the device token used by the current session.",
+		}),
+		supervisorState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tgo_agent_supervisor_state",
+			Help: "1 for the supervisor's current lifecycle state, 0 for all others.",
+		}, []string{"state"}),
+	}
+
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tgo_agent_build_info",
+		Help: "Always 1; labels carry the running build's version/os/arch.",
+	}, []string{"version", "os", "arch"})
+	buildInfo.WithLabelValues(version, goos, goarch).Set(1)
+
+	reg.MustRegister(r.toolCalls, r.reconnectAttempts, r.connected, r.tokenAgeSeconds, r.supervisorState, buildInfo)
+	return r
+}
+
+// ToolCall implements Metrics.
+func (r *Recorder) ToolCall(tool, status string) {
+	r.toolCalls.WithLabelValues(tool, status).Inc()
+}
+
+// ReconnectAttempt implements Metrics.
+func (r *Recorder) ReconnectAttempt() {
+	r.reconnectAttempts.Inc()
+}
+
+// SetConnected implements Metrics.
+func (r *Recorder) SetConnected(connected bool) {
+	r.connectedState.Store(connected)
+	if connected {
+		r.connected.Set(1)
+	} else {
+		r.connected.Set(0)
+	}
+}
+
+// SetTokenAge implements Metrics.
+func (r *Recorder) SetTokenAge(age time.Duration) {
+	r.tokenAgeSeconds.Set(age.Seconds())
+}
+
+// Connected reports the most recent value passed to SetConnected; it backs
+// the /healthz handler in Server.
+func (r *Recorder) Connected() bool {
+	return r.connectedState.Load()
+}
+
+// SetSupervisorState implements Metrics.
+func (r *Recorder) SetSupervisorState(state string) {
+	for _, s := range supervisorStates {
+		v := 0.0
+		if s == state {
+			v = 1
+		}
+		r.supervisorState.WithLabelValues(s).Set(v)
+	}
+}
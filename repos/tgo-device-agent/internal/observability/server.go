@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server serves /metrics and /healthz over a local HTTP listener. It's
+// optional: the agent only starts one when --metrics-addr is non-empty.
+type Server struct {
+	httpServer *http.Server
+}
+
+// NewServer builds a Server bound to addr, exposing rec's metrics and using
+// rec's connected state for /healthz.
+func NewServer(addr string, rec *Recorder) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(rec.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		if !rec.Connected() {
+			http.Error(w, "not connected", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+
+	return &Server{httpServer: &http.Server{Addr: addr, Handler: mux}}
+}
+
+// Run starts serving and blocks until ctx is cancelled, at which point it
+// shuts the listener down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+			return
+		}
+		errCh <- nil
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return s.httpServer.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
@@ -0,0 +1,165 @@
+package protocol
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// DeadlineConn wraps a net.Conn and enforces read/write deadlines using its
+// own timer-driven cancellation rather than relying on the underlying
+// connection's SetDeadline support. This lets the same deadline logic sit
+// in front of connections that don't implement real I/O deadlines as well
+// as ones that do. (The WebSocket transport does not use it; it derives a
+// per-call context from its deadlines instead.)
+//
+// Read and Write each run the underlying call in a goroutine and race it
+// against the configured deadline. If the deadline wins, the call returns a
+// net.Error with Timeout() == true while the underlying call is left to
+// finish (or error out) in the background. Callers must treat a timeout as
+// fatal to the connection – closing it rather than issuing further reads or
+// writes – since nothing stops the abandoned goroutine from still touching
+// the caller's buffer after the timeout is returned.
+type DeadlineConn struct {
+	net.Conn
+
+	readDeadline  *deadlineTimer
+	writeDeadline *deadlineTimer
+}
+
+// NewDeadlineConn wraps conn with independent read/write deadlines.
+func NewDeadlineConn(conn net.Conn) *DeadlineConn {
+	return &DeadlineConn{
+		Conn:          conn,
+		readDeadline:  newDeadlineTimer(),
+		writeDeadline: newDeadlineTimer(),
+	}
+}
+
+type ioResult struct {
+	n   int
+	err error
+}
+
+// Read implements net.Conn, subject to the current read deadline.
+func (c *DeadlineConn) Read(b []byte) (int, error) {
+	ch := make(chan ioResult, 1)
+	go func() {
+		n, err := c.Conn.Read(b)
+		ch <- ioResult{n, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.n, res.err
+	case <-c.readDeadline.wait():
+		return 0, timeoutError{}
+	}
+}
+
+// Write implements net.Conn, subject to the current write deadline.
+func (c *DeadlineConn) Write(b []byte) (int, error) {
+	ch := make(chan ioResult, 1)
+	go func() {
+		n, err := c.Conn.Write(b)
+		ch <- ioResult{n, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.n, res.err
+	case <-c.writeDeadline.wait():
+		return 0, timeoutError{}
+	}
+}
+
+// SetReadDeadline sets the deadline for future Read calls. A zero value
+// disables the deadline.
+func (c *DeadlineConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future Write calls. A zero value
+// disables the deadline.
+func (c *DeadlineConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (c *DeadlineConn) SetDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	c.writeDeadline.set(t)
+	return nil
+}
+
+// timeoutError is returned by Read/Write when their deadline fires.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "protocol: i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// deadlineTimer implements a cancellable deadline: a channel that closes
+// when the deadline elapses, which can be moved forward, pushed back, or
+// cleared entirely by repeated calls to set. This is the same pattern the
+// standard library's net.Pipe uses internally (see pipeDeadline in
+// src/net/pipe.go) to give an in-memory connection real deadline semantics.
+type deadlineTimer struct {
+	mu     sync.Mutex // guards timer and cancel
+	timer  *time.Timer
+	cancel chan struct{} // closed when the deadline elapses; never nil
+}
+
+func newDeadlineTimer() *deadlineTimer {
+	return &deadlineTimer{cancel: make(chan struct{})}
+}
+
+// set updates the deadline to t. A zero t clears the deadline. A t already
+// in the past fires immediately.
+func (d *deadlineTimer) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		// The timer already fired and closed d.cancel; drain that signal
+		// before replacing the channel so wait() can't see a stale close.
+		<-d.cancel
+	}
+	d.timer = nil
+
+	closed := isClosedChan(d.cancel)
+	switch {
+	case t.IsZero():
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+	case time.Until(t) > 0:
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(time.Until(t), func() { close(cancel) })
+	default:
+		if !closed {
+			close(d.cancel)
+		}
+	}
+}
+
+// wait returns the channel that closes once the current deadline elapses.
+// It never returns a channel that has already been superseded by a later
+// call to set, even if set races with a concurrent wait.
+func (d *deadlineTimer) wait() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
+
+func isClosedChan(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
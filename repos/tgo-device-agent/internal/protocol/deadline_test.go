@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDeadlineConn_ReadTimesOut(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	dc := NewDeadlineConn(client)
+	dc.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+
+	_, err := dc.Read(make([]byte, 10))
+	if err == nil {
+		t.Fatal("Read returned no error, want a timeout")
+	}
+	ne, ok := err.(net.Error)
+	if !ok || !ne.Timeout() {
+		t.Fatalf("Read error = %v, want a net.Error with Timeout() == true", err)
+	}
+}
+
+func TestDeadlineConn_ReadSucceedsBeforeDeadline(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	dc := NewDeadlineConn(client)
+	dc.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+
+	go server.Write([]byte("hi"))
+
+	buf := make([]byte, 2)
+	n, err := dc.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(buf[:n]) != "hi" {
+		t.Fatalf("Read = %q, want %q", buf[:n], "hi")
+	}
+}
+
+// TestDeadlineConn_ExtendingDeadlineDelaysTimeout verifies that pushing a
+// read deadline further into the future before it fires actually postpones
+// the timeout, rather than the original timer (armed on the first
+// SetReadDeadline call) firing on its original schedule regardless.
+func TestDeadlineConn_ExtendingDeadlineDelaysTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	dc := NewDeadlineConn(client)
+	dc.SetReadDeadline(time.Now().Add(30 * time.Millisecond))
+	time.Sleep(10 * time.Millisecond)
+	dc.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond) // past the original 30ms deadline
+		server.Write([]byte("ok"))
+	}()
+
+	buf := make([]byte, 2)
+	n, err := dc.Read(buf)
+	if err != nil {
+		t.Fatalf("Read returned %v, want the extended deadline to have let the write through", err)
+	}
+	if string(buf[:n]) != "ok" {
+		t.Fatalf("Read = %q, want %q", buf[:n], "ok")
+	}
+}
+
+// TestDeadlineConn_ResetAfterDeadlineAlreadyFired covers the tricky case
+// called out in deadlineTimer.set: a deadline that has already elapsed (its
+// timer fired and closed the cancel channel) must not leave that stale
+// closed channel in place when a fresh, later deadline is set afterward –
+// otherwise the very next Read would see an already-closed channel and time
+// out immediately regardless of the new deadline.
+func TestDeadlineConn_ResetAfterDeadlineAlreadyFired(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	dc := NewDeadlineConn(client)
+	dc.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	time.Sleep(30 * time.Millisecond) // let the first deadline's timer actually fire
+
+	dc.SetReadDeadline(time.Now().Add(150 * time.Millisecond))
+
+	go func() {
+		time.Sleep(40 * time.Millisecond)
+		server.Write([]byte("fresh"))
+	}()
+
+	start := time.Now()
+	buf := make([]byte, 5)
+	n, err := dc.Read(buf)
+	if err != nil {
+		t.Fatalf("Read returned %v after %v, want it to honor the new deadline instead of the stale fired one", err, time.Since(start))
+	}
+	if string(buf[:n]) != "fresh" {
+		t.Fatalf("Read = %q, want %q", buf[:n], "fresh")
+	}
+}
+
+// TestDeadlineConn_ClearDeadlineAfterFiring verifies that clearing the
+// deadline (the zero Time) after a previous deadline already fired puts the
+// connection back into "wait forever" mode rather than leaving it stuck
+// returning timeouts.
+func TestDeadlineConn_ClearDeadlineAfterFiring(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	dc := NewDeadlineConn(client)
+	dc.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	time.Sleep(30 * time.Millisecond) // let it fire
+
+	dc.SetReadDeadline(time.Time{})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.Write([]byte("z"))
+	}()
+
+	buf := make([]byte, 1)
+	n, err := dc.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v, want the cleared deadline to wait indefinitely", err)
+	}
+	if string(buf[:n]) != "z" {
+		t.Fatalf("Read = %q, want %q", buf[:n], "z")
+	}
+}
+
+func TestDeadlineConn_WriteTimesOut(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	dc := NewDeadlineConn(client)
+	dc.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+
+	// Nothing reads from server, so the write has nowhere to go and must
+	// time out rather than block forever.
+	_, err := dc.Write([]byte("hello"))
+	if err == nil {
+		t.Fatal("Write returned no error, want a timeout")
+	}
+	ne, ok := err.(net.Error)
+	if !ok || !ne.Timeout() {
+		t.Fatalf("Write error = %v, want a net.Error with Timeout() == true", err)
+	}
+}
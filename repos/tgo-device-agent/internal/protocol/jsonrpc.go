@@ -2,7 +2,10 @@
 // used for communication with tgo-device-control.
 package protocol
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+)
 
 // --------------------------------------------------------------------
 // JSON-RPC 2.0 message types
@@ -129,3 +132,31 @@ func IsResponse(raw json.RawMessage) bool {
 	}
 	return probe.Result != nil || probe.Error != nil
 }
+
+// --------------------------------------------------------------------
+// JSON-RPC 2.0 batch support
+// --------------------------------------------------------------------
+
+// IsBatch reports whether raw is a JSON-RPC batch – an array of requests –
+// by peeking the first non-whitespace byte, per the JSON-RPC 2.0 spec.
+func IsBatch(raw []byte) bool {
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// BatchResponse serializes a batch of responses as a JSON array, dropping
+// any nil entries (these correspond to notifications, which per spec get no
+// reply even inside a batch). It returns a nil slice, not "[]", if every
+// entry was a notification, signaling the caller to send no reply at all.
+func BatchResponse(responses []*Response) ([]byte, error) {
+	nonNil := make([]*Response, 0, len(responses))
+	for _, r := range responses {
+		if r != nil {
+			nonNil = append(nonNil, r)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(nonNil)
+}
@@ -0,0 +1,109 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func rawID(t *testing.T, id int) *json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(id)
+	if err != nil {
+		t.Fatalf("marshal id: %v", err)
+	}
+	rm := json.RawMessage(b)
+	return &rm
+}
+
+func TestIsBatch(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want bool
+	}{
+		{"array", `[{"jsonrpc":"2.0","id":1,"method":"ping"}]`, true},
+		{"array with leading whitespace", "  \n\t[1,2]", true},
+		{"single object", `{"jsonrpc":"2.0","id":1,"method":"ping"}`, false},
+		{"empty input", "", false},
+		{"whitespace only", "   ", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := IsBatch([]byte(c.raw)); got != c.want {
+				t.Errorf("IsBatch(%q) = %v, want %v", c.raw, got, c.want)
+			}
+		})
+	}
+}
+
+func TestBatchResponse_MixedRequestsAndNotifications(t *testing.T) {
+	resp1, err := NewResponse(rawID(t, 1), map[string]bool{"ok": true})
+	if err != nil {
+		t.Fatalf("NewResponse: %v", err)
+	}
+	resp2 := NewErrorResponse(rawID(t, 2), ErrMethodNotFound, "Method not found: bogus")
+
+	// A batch of three elements where the middle one was a notification
+	// (dispatchRequest returns nil for those) must produce a two-element
+	// reply array, skipping the nil and preserving the order of the rest.
+	out, err := BatchResponse([]*Response{resp1, nil, resp2})
+	if err != nil {
+		t.Fatalf("BatchResponse: %v", err)
+	}
+
+	var got []Response
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal batch reply: %v (raw: %s)", err, out)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d responses, want 2 (raw: %s)", len(got), out)
+	}
+	var gotID1, gotID2 int
+	if err := json.Unmarshal(*got[0].ID, &gotID1); err != nil || gotID1 != 1 {
+		t.Errorf("first response id = %v (err %v), want 1", gotID1, err)
+	}
+	if err := json.Unmarshal(*got[1].ID, &gotID2); err != nil || gotID2 != 2 {
+		t.Errorf("second response id = %v (err %v), want 2", gotID2, err)
+	}
+	if got[1].Error == nil || got[1].Error.Code != ErrMethodNotFound {
+		t.Errorf("second response error = %+v, want code %d", got[1].Error, ErrMethodNotFound)
+	}
+}
+
+func TestBatchResponse_AllNotifications(t *testing.T) {
+	// Every element was a notification, so dispatchRequest produced nil for
+	// each: per spec, the server sends back no reply at all – not an empty
+	// JSON array.
+	out, err := BatchResponse([]*Response{nil, nil, nil})
+	if err != nil {
+		t.Fatalf("BatchResponse: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("BatchResponse(all nil) = %q, want nil (not \"[]\")", out)
+	}
+}
+
+func TestBatchResponse_Empty(t *testing.T) {
+	out, err := BatchResponse(nil)
+	if err != nil {
+		t.Fatalf("BatchResponse: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("BatchResponse(nil) = %q, want nil", out)
+	}
+}
+
+func TestBatchDecode_PerElementParseError(t *testing.T) {
+	// A batch is decoded as a single []Request; per the JSON-RPC 2.0 spec, a
+	// single malformed element makes the whole batch unparsable (there's no
+	// way to know where one bad element ends and the next begins), so the
+	// caller must reject the entire batch with one Parse error rather than
+	// trying to salvage the well-formed elements around it.
+	raw := []byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":}]`)
+
+	var reqs []Request
+	err := json.Unmarshal(raw, &reqs)
+	if err == nil {
+		t.Fatalf("Unmarshal(%s) succeeded, want a parse error from the malformed second element", raw)
+	}
+}
@@ -1,5 +1,10 @@
 package protocol
 
+import (
+	"context"
+	"encoding/base64"
+)
+
 // ToolDefinition describes a tool in MCP-compatible format.
 type ToolDefinition struct {
 	Name        string                 `json:"name"`
@@ -12,24 +17,81 @@ type ToolsListResult struct {
 	Tools []ToolDefinition `json:"tools"`
 }
 
-// ToolCallParams is the params payload for "tools/call".
+// ToolCallParams is the params payload for "tools/call". TraceID is an
+// optional caller-supplied identifier (e.g. from an upstream OpenTelemetry
+// span) that's otherwise unrelated to the JSON-RPC request id; if present,
+// it's attached to the dispatch context alongside the call ID so it shows
+// up on every log line the tool call produces (see internal/logctx).
 type ToolCallParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments"`
+	TraceID   string                 `json:"traceId,omitempty"`
 }
 
 // ContentItem is one piece of content in a tool call result.
 type ContentItem struct {
-	Type     string `json:"type"`               // "text" or "image"
+	Type     string `json:"type"`           // "text", "image", or "blob"
 	Text     string `json:"text,omitempty"`
-	Data     string `json:"data,omitempty"`      // base64 for images
+	Data     string `json:"data,omitempty"` // base64 for "image" and "blob"
 	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"` // decoded byte size, "blob" only
+}
+
+// BlobResult is a convenience constructor for a single-blob tool result
+// (arbitrary binary content that isn't an image, e.g. a tar archive or PDF).
+func BlobResult(data []byte, mimeType string) *ToolCallResult {
+	return &ToolCallResult{
+		Content: []ContentItem{{
+			Type:     "blob",
+			Data:     base64.StdEncoding.EncodeToString(data),
+			MimeType: mimeType,
+			Size:     int64(len(data)),
+		}},
+	}
 }
 
 // ToolCallResult is the result payload for "tools/call".
 type ToolCallResult struct {
 	Content []ContentItem `json:"content"`
 	IsError bool          `json:"isError"`
+	// ExitCode and Truncated are populated by tools that stream progress
+	// (see "tools/progress" below) so non-streaming clients that only read
+	// the final response still learn how the command ended.
+	ExitCode  *int `json:"exitCode,omitempty"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// MethodToolsProgress is the notification method used to stream incremental
+// tool output. Clients that ignore it still get the full result in the
+// final "tools/call" response.
+const MethodToolsProgress = "tools/progress"
+
+// ToolProgressParams is the params payload for a "tools/progress" notification.
+type ToolProgressParams struct {
+	CallID   string `json:"callId"`
+	Sequence int    `json:"sequence"`
+	Chunk    string `json:"chunk,omitempty"`
+	Stream   string `json:"stream,omitempty"` // "stdout" or "stderr"
+	Done     bool   `json:"done,omitempty"`
+}
+
+// requestIDKey is the context key under which the originating JSON-RPC
+// request/call ID is stashed for the duration of a tools/call dispatch, so
+// tools several layers down (e.g. the journal) can tag what they record
+// without threading an extra parameter through every Tool.Execute call.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id, retrievable with
+// RequestIDFromContext.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stashed by
+// ContextWithRequestID, or "" if none was set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
 }
 
 // TextResult is a convenience constructor for a single-text tool result.
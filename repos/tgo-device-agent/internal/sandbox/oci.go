@@ -0,0 +1,275 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+)
+
+// ociSpec is a minimal subset of the OCI runtime-spec config.json fields
+// needed to sandbox a single "shell -c command" invocation. It intentionally
+// does not model the full spec (hooks, rlimits, user namespaces, ...) –
+// only what runc/crun need for a throwaway, isolated command.
+type ociSpec struct {
+	OCIVersion string     `json:"ociVersion"`
+	Process    ociProcess `json:"process"`
+	Root       ociRoot    `json:"root"`
+	Hostname   string     `json:"hostname,omitempty"`
+	Mounts     []ociMount `json:"mounts"`
+	Linux      ociLinux   `json:"linux"`
+}
+
+type ociProcess struct {
+	Terminal     bool             `json:"terminal"`
+	Cwd          string           `json:"cwd"`
+	Args         []string         `json:"args"`
+	Env          []string         `json:"env"`
+	Capabilities *ociCapabilities `json:"capabilities,omitempty"`
+}
+
+// ociCapabilities mirrors the OCI runtime-spec process.capabilities object:
+// five independent sets that together decide what the contained process
+// may do regardless of its uid. We populate all but ambient with the same
+// minimal bounding set so a command can still chown/chmod/setuid within
+// its own WorkRoot, but can't load kernel modules, change system time,
+// trace other processes, or otherwise act on the host.
+type ociCapabilities struct {
+	Bounding    []string `json:"bounding,omitempty"`
+	Effective   []string `json:"effective,omitempty"`
+	Inheritable []string `json:"inheritable,omitempty"`
+	Permitted   []string `json:"permitted,omitempty"`
+	Ambient     []string `json:"ambient,omitempty"`
+}
+
+type ociRoot struct {
+	Path     string `json:"path"`
+	Readonly bool   `json:"readonly"`
+}
+
+type ociMount struct {
+	Destination string   `json:"destination"`
+	Source      string   `json:"source,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type ociLinux struct {
+	Namespaces []ociNamespace `json:"namespaces"`
+	Resources  *ociResources  `json:"resources,omitempty"`
+	Seccomp    *ociSeccomp    `json:"seccomp,omitempty"`
+}
+
+type ociNamespace struct {
+	Type string `json:"type"`
+}
+
+type ociResources struct {
+	Memory *ociMemory `json:"memory,omitempty"`
+	CPU    *ociCPU    `json:"cpu,omitempty"`
+	Pids   *ociPids   `json:"pids,omitempty"`
+}
+
+type ociMemory struct {
+	Limit int64 `json:"limit"`
+}
+
+type ociCPU struct {
+	Quota  int64 `json:"quota"`
+	Period int64 `json:"period"`
+}
+
+type ociPids struct {
+	Limit int64 `json:"limit"`
+}
+
+type ociSeccomp struct {
+	DefaultAction string       `json:"defaultAction"`
+	Architectures []string     `json:"architectures,omitempty"`
+	Syscalls      []ociSyscall `json:"syscalls,omitempty"`
+}
+
+type ociSyscall struct {
+	Names  []string `json:"names"`
+	Action string   `json:"action"`
+}
+
+// defaultDeniedSyscalls blocks the handful of syscalls most commonly used to
+// break out of or destabilize a container (kernel module loading, mount
+// manipulation, raw reboot/swap control). Everything else defaults to allow.
+var defaultDeniedSyscalls = []string{
+	"init_module", "finit_module", "delete_module",
+	"mount", "umount2", "pivot_root",
+	"reboot", "swapon", "swapoff",
+	"kexec_load", "kexec_file_load",
+}
+
+// defaultBoundingCapabilities is what's left in the container process's
+// capability sets after dropping everything else. It's enough to
+// chown/chmod/setuid|setgid files the command creates inside WorkRoot, but
+// withholds anything that acts on the host or kernel (CAP_SYS_ADMIN,
+// CAP_SYS_MODULE, CAP_SYS_PTRACE, CAP_NET_ADMIN, CAP_SYS_TIME, ...).
+var defaultBoundingCapabilities = []string{
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_FOWNER", "CAP_FSETID",
+	"CAP_SETGID", "CAP_SETUID", "CAP_SETPCAP",
+}
+
+// OCIRunner executes shell commands inside a throwaway OCI-spec container
+// via an external low-level runtime (runc, crun), giving real mount/PID/IPC
+// namespace isolation instead of relying solely on the blocked-command list.
+type OCIRunner struct {
+	sb          *Sandbox
+	runtimePath string
+}
+
+// NewOCIRunner locates the configured (or auto-detected) OCI runtime binary.
+// It returns an error if none can be found, so callers can fall back to the
+// host exec backend.
+func NewOCIRunner(sb *Sandbox, cfg *config.Config) (*OCIRunner, error) {
+	path := cfg.OCIRuntimePath
+	if path == "" {
+		var err error
+		path, err = exec.LookPath("runc")
+		if err != nil {
+			path, err = exec.LookPath("crun")
+			if err != nil {
+				return nil, fmt.Errorf("no OCI runtime (runc, crun) found in PATH: %w", err)
+			}
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("configured oci_runtime_path %q: %w", path, err)
+	}
+
+	return &OCIRunner{sb: sb, runtimePath: path}, nil
+}
+
+// Run executes `shell -c command` inside a fresh container bundle and
+// streams its stdout/stderr into the given writers. It returns the
+// process exit code (or -1 if the runtime could not be started at all).
+func (o *OCIRunner) Run(ctx context.Context, cfg *config.Config, shell, command, cwd string, env []string, stdout, stderr io.Writer) (int, error) {
+	bundle, err := os.MkdirTemp("", "tgo-oci-*")
+	if err != nil {
+		return -1, fmt.Errorf("create bundle dir: %w", err)
+	}
+	defer os.RemoveAll(bundle)
+
+	rootfs := filepath.Join(bundle, "rootfs")
+	if err := os.Mkdir(rootfs, 0o755); err != nil {
+		return -1, fmt.Errorf("create rootfs dir: %w", err)
+	}
+
+	spec := o.buildSpec(cfg, rootfs, shell, command, cwd, env)
+	if err := ensureMountpoints(rootfs, spec.Mounts); err != nil {
+		return -1, fmt.Errorf("prepare rootfs mountpoints: %w", err)
+	}
+	specBytes, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return -1, fmt.Errorf("marshal oci spec: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, "config.json"), specBytes, 0o644); err != nil {
+		return -1, fmt.Errorf("write config.json: %w", err)
+	}
+
+	containerID := fmt.Sprintf("tgo-%d", os.Getpid())
+	cmd := exec.CommandContext(ctx, o.runtimePath, "run", "--bundle", bundle, containerID)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	runErr := cmd.Run()
+	if runErr == nil {
+		return 0, nil
+	}
+	if exitErr, ok := runErr.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	return -1, fmt.Errorf("runc run: %w", runErr)
+}
+
+// ensureMountpoints pre-creates each mount's destination directory inside
+// rootfs. rootfs starts out empty (buildSpec points Root.Path at it rather
+// than at "/"), so without this the runtime has nowhere to bind the host
+// paths it's explicitly given onto.
+func ensureMountpoints(rootfs string, mounts []ociMount) error {
+	for _, m := range mounts {
+		if err := os.MkdirAll(filepath.Join(rootfs, m.Destination), 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildSpec assembles the per-invocation OCI config.json: a minimal empty
+// rootfs at rootfsPath with only /proc, /dev, a read-only bind-mount of the
+// host's /usr /lib /bin, and the sandbox WorkRoot bind-mounted read-write
+// as the container's cwd – never the host's actual "/" – plus a dropped
+// capability set and cgroup v2 resource limits sourced from config.Config.
+func (o *OCIRunner) buildSpec(cfg *config.Config, rootfsPath, shell, command, cwd string, env []string) ociSpec {
+	workRoot := o.sb.WorkRoot()
+	if cwd == "" {
+		cwd = workRoot
+	}
+
+	mounts := []ociMount{
+		{Destination: "/proc", Type: "proc", Source: "proc"},
+		{Destination: "/dev", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "strictatime", "mode=755", "size=65536k"}},
+	}
+	for _, ro := range []string{"/usr", "/lib", "/bin"} {
+		if _, err := os.Stat(ro); err != nil {
+			continue
+		}
+		mounts = append(mounts, ociMount{
+			Destination: ro,
+			Source:      ro,
+			Type:        "bind",
+			Options:     []string{"bind", "ro"},
+		})
+	}
+	mounts = append(mounts, ociMount{
+		Destination: workRoot,
+		Source:      workRoot,
+		Type:        "bind",
+		Options:     []string{"bind", "rw"},
+	})
+
+	return ociSpec{
+		OCIVersion: "1.0.2",
+		Process: ociProcess{
+			Cwd:  cwd,
+			Args: []string{shell, "-c", command},
+			Env:  env,
+			Capabilities: &ociCapabilities{
+				Bounding:    defaultBoundingCapabilities,
+				Effective:   defaultBoundingCapabilities,
+				Permitted:   defaultBoundingCapabilities,
+				Inheritable: defaultBoundingCapabilities,
+			},
+		},
+		Root:   ociRoot{Path: rootfsPath, Readonly: true},
+		Mounts: mounts,
+		Linux: ociLinux{
+			Namespaces: []ociNamespace{
+				{Type: "pid"},
+				{Type: "ipc"},
+				{Type: "uts"},
+				{Type: "mount"},
+			},
+			Resources: &ociResources{
+				Memory: &ociMemory{Limit: cfg.OCIMemoryLimitMB * 1024 * 1024},
+				CPU:    &ociCPU{Quota: int64(cfg.OCICPUQuotaPct) * 1000, Period: 100000},
+				Pids:   &ociPids{Limit: cfg.OCIPidsLimit},
+			},
+			Seccomp: &ociSeccomp{
+				DefaultAction: "SCMP_ACT_ALLOW",
+				Architectures: []string{"SCMP_ARCH_X86_64", "SCMP_ARCH_AARCH64"},
+				Syscalls: []ociSyscall{
+					{Names: defaultDeniedSyscalls, Action: "SCMP_ACT_ERRNO"},
+				},
+			},
+		},
+	}
+}
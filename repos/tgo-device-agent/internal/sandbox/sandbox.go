@@ -3,6 +3,7 @@
 package sandbox
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -71,8 +72,10 @@ func (s *Sandbox) WorkRoot() string {
 }
 
 // ResolvePath resolves a potentially relative path and validates it
-// is within the allowed paths.
-func (s *Sandbox) ResolvePath(path string) (string, error) {
+// is within the allowed paths. ctx is used only to carry the calling tool
+// call's correlation fields (device_id, project_id, tool, ...) onto any
+// denial log line; pass context.Background() if none is available.
+func (s *Sandbox) ResolvePath(ctx context.Context, path string) (string, error) {
 	if path == "" {
 		return "", fmt.Errorf("empty path")
 	}
@@ -107,19 +110,23 @@ func (s *Sandbox) ResolvePath(path string) (string, error) {
 
 	// Check path is within allowed paths
 	if !s.isAllowed(realPath) {
+		slog.WarnContext(ctx, "sandbox denied path", "path", path, "denied_reason", "outside_allowed_paths")
 		return "", fmt.Errorf("path '%s' is outside allowed directories", path)
 	}
 
 	// Check path is not in denied paths
 	if s.isDenied(realPath) {
+		slog.WarnContext(ctx, "sandbox denied path", "path", path, "denied_reason", "denied_path")
 		return "", fmt.Errorf("path '%s' is in a denied directory", path)
 	}
 
 	return resolved, nil
 }
 
-// ValidateWrite performs additional validation for write operations.
-func (s *Sandbox) ValidateWrite(resolvedPath string) error {
+// ValidateWrite performs additional validation for write operations. ctx
+// carries the calling tool call's correlation fields onto any denial log
+// line; pass context.Background() if none is available.
+func (s *Sandbox) ValidateWrite(ctx context.Context, resolvedPath string) error {
 	// Prevent writing to critical system paths
 	criticalPaths := []string{
 		"/etc", "/usr", "/bin", "/sbin", "/var", "/System",
@@ -128,6 +135,7 @@ func (s *Sandbox) ValidateWrite(resolvedPath string) error {
 
 	for _, cp := range criticalPaths {
 		if strings.HasPrefix(resolvedPath, cp+"/") || resolvedPath == cp {
+			slog.WarnContext(ctx, "sandbox denied write", "path", resolvedPath, "denied_reason", "critical_system_path")
 			return fmt.Errorf("write to system path '%s' is not allowed", resolvedPath)
 		}
 	}
@@ -135,12 +143,15 @@ func (s *Sandbox) ValidateWrite(resolvedPath string) error {
 	return nil
 }
 
-// ValidateCommand checks if a shell command is allowed.
-func (s *Sandbox) ValidateCommand(command string) error {
+// ValidateCommand checks if a shell command is allowed. ctx carries the
+// calling tool call's correlation fields onto any denial log line; pass
+// context.Background() if none is available.
+func (s *Sandbox) ValidateCommand(ctx context.Context, command string) error {
 	lower := strings.ToLower(strings.TrimSpace(command))
 
 	for _, blocked := range s.blockedCmds {
 		if strings.Contains(lower, strings.ToLower(blocked)) {
+			slog.WarnContext(ctx, "sandbox denied command", "denied_reason", "blocked_pattern:"+blocked)
 			return fmt.Errorf("command contains blocked pattern '%s'", blocked)
 		}
 	}
@@ -159,6 +170,7 @@ func (s *Sandbox) ValidateCommand(command string) error {
 
 	for _, pattern := range dangerousPatterns {
 		if strings.Contains(lower, strings.ToLower(pattern)) {
+			slog.WarnContext(ctx, "sandbox denied command", "denied_reason", "dangerous_pattern:"+pattern)
 			return fmt.Errorf("command matches dangerous pattern '%s'", pattern)
 		}
 	}
@@ -0,0 +1,122 @@
+// Package supervisor restarts a transport.Client across process-level
+// failures it gives up on entirely — after its own internal reconnect
+// budget (see transport.Client.Run) is exhausted, or a fatal error like an
+// invalid token comes back. This is a level above Client's own
+// disconnect/reconnect handling, not a replacement for it: ordinary network
+// blips never reach here. The context-based Serve(ctx) error contract and
+// backoff strategy follow suture v4's conventions.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/observability"
+	"github.com/tgoai/tgo-device-agent/internal/transport"
+)
+
+const (
+	baseDelay = 1 * time.Second
+	maxDelay  = 60 * time.Second
+)
+
+// Runnable is the subset of *transport.Client that Supervisor restarts.
+type Runnable interface {
+	Run(ctx context.Context) error
+}
+
+// Supervisor restarts a Runnable on transient errors with exponential
+// backoff and full jitter, and stops for good on a fatal one.
+type Supervisor struct {
+	client  Runnable
+	cfg     *config.Config
+	metrics observability.Metrics
+}
+
+// New creates a Supervisor wrapping client. metrics may be nil, in which
+// case the supervisor-state gauge simply isn't recorded.
+func New(client Runnable, cfg *config.Config, metrics observability.Metrics) *Supervisor {
+	return &Supervisor{client: client, cfg: cfg, metrics: metrics}
+}
+
+// Serve runs client.Run in a loop until ctx is cancelled (clean shutdown,
+// returns nil) or transport.ErrAuthFailed ends it (fatal: the saved device
+// token is wiped so the next "run" fails fast with a clear "re-bind" error
+// instead of looping on a credential the server will keep rejecting).
+// Any other error is treated as transient: client.Run is restarted after a
+// delay of base 1s, doubling per attempt, capped at 60s, with full jitter
+// (a uniform random draw over [0, cap)).
+func (s *Supervisor) Serve(ctx context.Context) error {
+	attempt := 0
+	for {
+		select {
+		case <-ctx.Done():
+			s.setState("stopped")
+			return nil
+		default:
+		}
+
+		s.setState("running")
+		err := s.client.Run(ctx)
+		if err == nil || errors.Is(err, context.Canceled) {
+			s.setState("stopped")
+			return nil
+		}
+
+		if errors.Is(err, transport.ErrAuthFailed) {
+			s.setState("fatal")
+			slog.Error("supervisor stopping: authentication failed, wiping saved token", "error", err)
+			if rmErr := os.Remove(s.cfg.TokenFile); rmErr != nil && !os.IsNotExist(rmErr) {
+				slog.Warn("failed to wipe device token file", "path", s.cfg.TokenFile, "error", rmErr)
+			}
+			return err
+		}
+
+		attempt++
+		delay := backoff(attempt)
+		s.setState("backoff")
+		slog.Warn("supervisor restarting client",
+			"error", err,
+			"attempt", attempt,
+			"delay", delay,
+		)
+
+		select {
+		case <-ctx.Done():
+			s.setState("stopped")
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// backoff returns the delay before restart attempt n (1-indexed): base*2^(n-1),
+// capped at maxDelay, then jittered by drawing uniformly from [0, cap) —
+// "full jitter", which spreads out restarts better than a fixed or
+// half-jittered delay when many devices fail around the same time.
+func backoff(attempt int) time.Duration {
+	delayCap := baseDelay
+	if attempt > 1 {
+		shift := attempt - 1
+		if shift > 6 { // 2^6 * 1s == 64s already exceeds maxDelay
+			shift = 6
+		}
+		delayCap = baseDelay * time.Duration(int64(1)<<uint(shift))
+	}
+	if delayCap > maxDelay {
+		delayCap = maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delayCap)))
+}
+
+// setState reports state to metrics, if configured.
+func (s *Supervisor) setState(state string) {
+	if s.metrics != nil {
+		s.metrics.SetSupervisorState(state)
+	}
+}
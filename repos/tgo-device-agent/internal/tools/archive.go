@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgoai/tgo-device-agent/internal/sandbox"
+)
+
+// archiveDir walks root and writes every entry under it into a tar stream
+// (gzip-compressed when gzipped is true), relative to root. include/exclude
+// are glob patterns matched against each entry's root-relative path; when
+// include is non-empty an entry must match at least one include pattern,
+// and a match against any exclude pattern always wins. Symlinks are
+// archived as symlinks (not followed) unless followSymlinks is set.
+func archiveDir(root string, gzipped bool, include, exclude []string, followSymlinks bool) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+
+	walkErr := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if !matchesFilter(rel, include, exclude) {
+			return nil
+		}
+
+		walkPath := path
+		if info.Mode()&os.ModeSymlink != 0 && followSymlinks {
+			resolved, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return fmt.Errorf("resolve symlink %q: %w", rel, err)
+			}
+			walkPath = resolved
+			info, err = os.Stat(resolved)
+			if err != nil {
+				return err
+			}
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(walkPath)
+			if err != nil {
+				return err
+			}
+			link = target
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			f, err := os.Open(walkPath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(tw, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// matchesFilter reports whether rel passes the include/exclude glob filters.
+func matchesFilter(rel string, include, exclude []string) bool {
+	for _, pat := range exclude {
+		if ok, _ := filepath.Match(pat, rel); ok {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pat := range include {
+		if ok, _ := filepath.Match(pat, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extractTar extracts a tar stream (gzip-compressed when gzipped is true)
+// into destRoot beneath the sandbox, one entry at a time. Every entry's
+// resolved path is validated through sb.ResolvePath/ValidateWrite so none
+// can escape the sandbox via ".." components or an absolute path; such
+// entries abort the extraction rather than being silently skipped.
+// Symlink entries are rejected outright since their target can't be
+// validated against the sandbox the same way. written is the cumulative
+// size of extracted regular files, checked against maxBytes as it grows.
+func extractTar(ctx context.Context, sb *sandbox.Sandbox, destRoot string, data []byte, gzipped bool, maxBytes int64, journal func(ctx context.Context, resolved string)) (written int64, paths []string, err error) {
+	var r io.Reader = bytes.NewReader(data)
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return 0, nil, fmt.Errorf("open gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, paths, fmt.Errorf("read tar entry: %w", err)
+		}
+
+		if filepath.IsAbs(hdr.Name) {
+			return written, paths, fmt.Errorf("tar entry %q has an absolute path", hdr.Name)
+		}
+		cleaned := filepath.Clean(hdr.Name)
+		if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+			return written, paths, fmt.Errorf("tar entry %q escapes the archive root", hdr.Name)
+		}
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			return written, paths, fmt.Errorf("tar entry %q is a symlink, which is not allowed", hdr.Name)
+		}
+
+		resolved, err := sb.ResolvePath(ctx, filepath.Join(destRoot, cleaned))
+		if err != nil {
+			return written, paths, fmt.Errorf("tar entry %q: %w", hdr.Name, err)
+		}
+		if err := sb.ValidateWrite(ctx, resolved); err != nil {
+			return written, paths, fmt.Errorf("tar entry %q: %w", hdr.Name, err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(resolved, 0o755); err != nil {
+				return written, paths, err
+			}
+		case tar.TypeReg:
+			if written+hdr.Size > maxBytes {
+				return written, paths, fmt.Errorf("archive exceeds max write size (%d bytes)", maxBytes)
+			}
+			if err := os.MkdirAll(filepath.Dir(resolved), 0o755); err != nil {
+				return written, paths, err
+			}
+			if journal != nil {
+				journal(ctx, resolved)
+			}
+			f, err := os.OpenFile(resolved, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+			if err != nil {
+				return written, paths, err
+			}
+			n, copyErr := io.Copy(f, tr)
+			f.Close()
+			if copyErr != nil {
+				return written, paths, copyErr
+			}
+			written += n
+			paths = append(paths, cleaned)
+		default:
+			// Devices, fifos, etc. aren't meaningful inside a sandboxed
+			// write and are skipped rather than rejected outright.
+		}
+	}
+	return written, paths, nil
+}
+
+// stringSliceArg extracts a []string argument (as produced by a JSON array)
+// from the args map.
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	raw, ok := args[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
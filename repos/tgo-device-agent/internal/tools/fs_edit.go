@@ -3,10 +3,13 @@ package tools
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/journal"
 	"github.com/tgoai/tgo-device-agent/internal/protocol"
 	"github.com/tgoai/tgo-device-agent/internal/sandbox"
 )
@@ -15,11 +18,18 @@ import (
 type FSEdit struct {
 	sb  *sandbox.Sandbox
 	cfg *config.Config
+	jr  *journal.Journal // nil disables journaling (fs_history/fs_revert unavailable)
+	log *slog.Logger
 }
 
-// NewFSEdit creates a new FSEdit tool.
-func NewFSEdit(sb *sandbox.Sandbox, cfg *config.Config) *FSEdit {
-	return &FSEdit{sb: sb, cfg: cfg}
+// NewFSEdit creates a new FSEdit tool. jr may be nil, in which case edits
+// are not journaled. log is the "fs" tool group's logger (see
+// internal/logging.SetupLoggers); it defaults to slog.Default() if nil.
+func NewFSEdit(sb *sandbox.Sandbox, cfg *config.Config, jr *journal.Journal, log *slog.Logger) *FSEdit {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &FSEdit{sb: sb, cfg: cfg, jr: jr, log: log}
 }
 
 func (t *FSEdit) Name() string { return "fs_edit" }
@@ -27,13 +37,13 @@ func (t *FSEdit) Name() string { return "fs_edit" }
 func (t *FSEdit) Definition() protocol.ToolDefinition {
 	return protocol.ToolDefinition{
 		Name:        "fs_edit",
-		Description: "Perform exact string replacement in a file. Finds 'old_string' and replaces it with 'new_string'. By default replaces only the first occurrence; set 'replace_all' to true to replace all occurrences. The edit will fail if old_string is not found or is ambiguous (multiple matches when replace_all is false).",
+		Description: "Edit one or more files. Either (a) perform exact string replacement within a single file via 'path'/'old_string'/'new_string', or (b) apply a unified diff (as produced by `diff -u` or `git diff`) covering one or more files via 'patch', including hunks that create or delete a whole file (a '/dev/null' old or new path). A patch is applied atomically: if any hunk's context can't be located, no file is touched.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"path": map[string]interface{}{
 					"type":        "string",
-					"description": "Path to the file to edit",
+					"description": "Path to the file to edit. Used with old_string/new_string; ignored when 'patch' is given.",
 				},
 				"old_string": map[string]interface{}{
 					"type":        "string",
@@ -48,13 +58,20 @@ func (t *FSEdit) Definition() protocol.ToolDefinition {
 					"description": "If true, replace all occurrences. Default: false",
 					"default":     false,
 				},
+				"patch": map[string]interface{}{
+					"type":        "string",
+					"description": "A unified diff to apply across one or more files, in place of path/old_string/new_string.",
+				},
 			},
-			"required": []string{"path", "old_string", "new_string"},
 		},
 	}
 }
 
-func (t *FSEdit) Execute(_ context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+func (t *FSEdit) Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+	if patchText, ok := args["patch"].(string); ok && patchText != "" {
+		return t.applyPatch(ctx, patchText)
+	}
+
 	path, _ := args["path"].(string)
 	oldStr, _ := args["old_string"].(string)
 	newStr, _ := args["new_string"].(string)
@@ -68,12 +85,12 @@ func (t *FSEdit) Execute(_ context.Context, args map[string]interface{}) *protoc
 	}
 
 	// Sandbox validation
-	resolved, err := t.sb.ResolvePath(path)
+	resolved, err := t.sb.ResolvePath(ctx, path)
 	if err != nil {
 		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
 
-	if err := t.sb.ValidateWrite(resolved); err != nil {
+	if err := t.sb.ValidateWrite(ctx, resolved); err != nil {
 		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
 
@@ -120,6 +137,12 @@ func (t *FSEdit) Execute(_ context.Context, args map[string]interface{}) *protoc
 		)
 	}
 
+	if t.jr != nil {
+		if _, err := t.jr.Record("fs_edit", resolved, protocol.RequestIDFromContext(ctx), data, true); err != nil {
+			t.log.WarnContext(ctx, "fs_edit: failed to record journal entry", "path", resolved, "error", err)
+		}
+	}
+
 	// Write back
 	if err := os.WriteFile(resolved, []byte(newContent), 0o644); err != nil {
 		return protocol.TextResult(fmt.Sprintf("Error writing file: %v", err), true)
@@ -135,3 +158,230 @@ func (t *FSEdit) Execute(_ context.Context, args map[string]interface{}) *protoc
 		false,
 	)
 }
+
+// stagedPatchFile is one file's resolved outcome from a unified diff,
+// staged in memory until the whole patch has been validated.
+type stagedPatchFile struct {
+	path       string // path as named in the patch, for the summary/errors
+	resolved   string
+	tmpPath    string
+	content    []byte
+	delete     bool
+	action     string // "modified", "created", or "deleted"
+	applied    int
+	preContent []byte // content before this hunk/delete, for journaling
+	existed    bool   // false only for a new-file hunk
+}
+
+// applyPatch implements fs_edit's 'patch' argument: it parses patchText as
+// a unified diff, applies every file's hunks in memory, validates every
+// touched path and the combined size against the sandbox, then commits by
+// writing ".tmp" siblings and renaming them into place. If any hunk can't
+// be located, or any touched path fails sandbox validation, nothing is
+// written – the patch is all-or-nothing.
+func (t *FSEdit) applyPatch(ctx context.Context, patchText string) *protocol.ToolCallResult {
+	files, err := parsePatch(patchText)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error parsing patch: %v", err), true)
+	}
+	if len(files) == 0 {
+		return protocol.TextResult("Error: patch contains no file changes", true)
+	}
+
+	var staged []stagedPatchFile
+	var totalBytes int64
+
+	for _, fp := range files {
+		resolved, err := t.sb.ResolvePath(ctx, fp.path)
+		if err != nil {
+			return protocol.TextResult(fmt.Sprintf("Error: %s: %v", fp.path, err), true)
+		}
+		if err := t.sb.ValidateWrite(ctx, resolved); err != nil {
+			return protocol.TextResult(fmt.Sprintf("Error: %s: %v", fp.path, err), true)
+		}
+
+		if fp.isDelete {
+			data, readErr := os.ReadFile(resolved)
+			if readErr != nil {
+				if os.IsNotExist(readErr) {
+					return protocol.TextResult(fmt.Sprintf("Error: %s: file not found", fp.path), true)
+				}
+				return protocol.TextResult(fmt.Sprintf("Error reading %s: %v", fp.path, readErr), true)
+			}
+
+			// A delete hunk is still a hunk: its "-" lines are the pre-image
+			// the patch expects to find, so run it through the same
+			// locateBlock context check as a modify/create hunk rather than
+			// trusting that the file hasn't diverged since the patch was
+			// generated.
+			_, results, err := applyHunks(string(data), fp.hunks)
+			if err != nil {
+				return protocol.TextResult(fmt.Sprintf("Error applying patch to %s: %v", fp.path, err), true)
+			}
+			var rejected []string
+			for _, r := range results {
+				if !r.applied {
+					rejected = append(rejected, fmt.Sprintf("%s: %s", r.header, r.reason))
+				}
+			}
+			if len(rejected) > 0 {
+				return protocol.TextResult(fmt.Sprintf(
+					"Error: %d of %d hunk(s) in %s could not be located; no files were changed:\n%s",
+					len(rejected), len(results), fp.path, strings.Join(rejected, "\n"),
+				), true)
+			}
+
+			staged = append(staged, stagedPatchFile{
+				path: fp.path, resolved: resolved, delete: true,
+				action: "deleted", applied: len(fp.hunks),
+				preContent: data, existed: true,
+			})
+			continue
+		}
+
+		var original string
+		existed := true
+		if fp.isCreate {
+			if _, statErr := os.Stat(resolved); statErr == nil {
+				return protocol.TextResult(fmt.Sprintf("Error: %s: file already exists, cannot apply a new-file hunk", fp.path), true)
+			}
+			existed = false
+		} else {
+			data, readErr := os.ReadFile(resolved)
+			if readErr != nil {
+				if os.IsNotExist(readErr) {
+					return protocol.TextResult(fmt.Sprintf("Error: %s: file not found", fp.path), true)
+				}
+				return protocol.TextResult(fmt.Sprintf("Error reading %s: %v", fp.path, readErr), true)
+			}
+			original = string(data)
+		}
+
+		newContent, results, err := applyHunks(original, fp.hunks)
+		if err != nil {
+			return protocol.TextResult(fmt.Sprintf("Error applying patch to %s: %v", fp.path, err), true)
+		}
+
+		var rejected []string
+		applied := 0
+		for _, r := range results {
+			if r.applied {
+				applied++
+			} else {
+				rejected = append(rejected, fmt.Sprintf("%s: %s", r.header, r.reason))
+			}
+		}
+		if len(rejected) > 0 {
+			return protocol.TextResult(fmt.Sprintf(
+				"Error: %d of %d hunk(s) in %s could not be located; no files were changed:\n%s",
+				len(rejected), len(results), fp.path, strings.Join(rejected, "\n"),
+			), true)
+		}
+
+		totalBytes += int64(len(newContent))
+		action := "modified"
+		if fp.isCreate {
+			action = "created"
+		}
+		staged = append(staged, stagedPatchFile{
+			path: fp.path, resolved: resolved, tmpPath: resolved + ".patch.tmp",
+			content: []byte(newContent), action: action, applied: applied,
+			preContent: []byte(original), existed: existed,
+		})
+	}
+
+	if totalBytes > t.cfg.MaxWriteBytes {
+		return protocol.TextResult(
+			fmt.Sprintf("Error: total patched content too large (%d bytes, max %d bytes)", totalBytes, t.cfg.MaxWriteBytes),
+			true,
+		)
+	}
+
+	var written []string
+	for _, s := range staged {
+		if s.delete {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(s.resolved), 0o755); err != nil {
+			removeFiles(written)
+			return protocol.TextResult(fmt.Sprintf("Error creating directories for %s: %v", s.path, err), true)
+		}
+		if err := os.WriteFile(s.tmpPath, s.content, 0o644); err != nil {
+			removeFiles(written)
+			return protocol.TextResult(fmt.Sprintf("Error staging %s: %v", s.path, err), true)
+		}
+		written = append(written, s.tmpPath)
+	}
+
+	// From here on every file is committed in place, so a failure partway
+	// through must roll back everything already committed rather than
+	// leaving the patch half-applied, per Definition()'s atomicity promise.
+	var committed []stagedPatchFile
+	for _, s := range staged {
+		if s.delete {
+			continue
+		}
+		if err := os.Rename(s.tmpPath, s.resolved); err != nil {
+			removeFiles(written)
+			t.rollbackCommitted(ctx, committed)
+			return protocol.TextResult(fmt.Sprintf("Error committing %s: %v", s.path, err), true)
+		}
+		committed = append(committed, s)
+		t.journal(ctx, s)
+	}
+	for _, s := range staged {
+		if !s.delete {
+			continue
+		}
+		if err := os.Remove(s.resolved); err != nil && !os.IsNotExist(err) {
+			t.rollbackCommitted(ctx, committed)
+			return protocol.TextResult(fmt.Sprintf("Error deleting %s: %v", s.path, err), true)
+		}
+		committed = append(committed, s)
+		t.journal(ctx, s)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Successfully applied patch across %d file(s):\n", len(staged))
+	for _, s := range staged {
+		fmt.Fprintf(&b, "  %s: %s (%d hunk(s) applied)\n", s.action, s.path, s.applied)
+	}
+	return protocol.TextResult(strings.TrimRight(b.String(), "\n"), false)
+}
+
+// journal records s's pre-commit content for a single committed patch file,
+// if journaling is enabled. Failures are logged and otherwise ignored.
+func (t *FSEdit) journal(ctx context.Context, s stagedPatchFile) {
+	if t.jr == nil {
+		return
+	}
+	requestID := protocol.RequestIDFromContext(ctx)
+	if _, err := t.jr.Record("fs_edit", s.resolved, requestID, s.preContent, s.existed); err != nil {
+		t.log.WarnContext(ctx, "fs_edit: failed to record journal entry", "path", s.resolved, "error", err)
+	}
+}
+
+// removeFiles best-effort removes staged .tmp files after a failed commit.
+func removeFiles(paths []string) {
+	for _, p := range paths {
+		os.Remove(p)
+	}
+}
+
+// rollbackCommitted best-effort restores every already-committed file in
+// committed back to its pre-patch state, in reverse commit order, so a
+// rename or delete failing partway through the commit loops doesn't leave
+// the patch half-applied. A rollback failure is logged rather than
+// returned: the caller is already reporting the original commit error.
+func (t *FSEdit) rollbackCommitted(ctx context.Context, committed []stagedPatchFile) {
+	for i := len(committed) - 1; i >= 0; i-- {
+		s := committed[i]
+		if s.existed {
+			if err := os.WriteFile(s.resolved, s.preContent, 0o644); err != nil {
+				t.log.WarnContext(ctx, "fs_edit: rollback failed to restore file", "path", s.resolved, "error", err)
+			}
+		} else if err := os.Remove(s.resolved); err != nil && !os.IsNotExist(err) {
+			t.log.WarnContext(ctx, "fs_edit: rollback failed to remove file", "path", s.resolved, "error", err)
+		}
+	}
+}
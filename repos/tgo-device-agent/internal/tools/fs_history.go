@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/tgoai/tgo-device-agent/internal/journal"
+	"github.com/tgoai/tgo-device-agent/internal/protocol"
+	"github.com/tgoai/tgo-device-agent/internal/sandbox"
+)
+
+// FSHistory implements the fs_history tool – lists recent fs_write/fs_edit
+// mutations recorded in the journal.
+type FSHistory struct {
+	sb  *sandbox.Sandbox
+	jr  *journal.Journal
+	log *slog.Logger
+}
+
+// NewFSHistory creates a new FSHistory tool. log is the "fs" tool group's
+// logger (see internal/logging.SetupLoggers); it defaults to
+// slog.Default() if nil.
+func NewFSHistory(sb *sandbox.Sandbox, jr *journal.Journal, log *slog.Logger) *FSHistory {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &FSHistory{sb: sb, jr: jr, log: log}
+}
+
+func (t *FSHistory) Name() string { return "fs_history" }
+
+func (t *FSHistory) Definition() protocol.ToolDefinition {
+	return protocol.ToolDefinition{
+		Name:        "fs_history",
+		Description: "List recent fs_write/fs_edit mutations, most recent first, each with the journal entry ID needed by fs_revert. Optionally filter to a single path.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "If given, only list mutations to this file",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of entries to return. Default: 20",
+					"default":     20,
+				},
+			},
+		},
+	}
+}
+
+func (t *FSHistory) Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+	limit := 20
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+
+	var pathFilter string
+	if p, ok := args["path"].(string); ok && p != "" {
+		resolved, err := t.sb.ResolvePath(ctx, p)
+		if err != nil {
+			return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
+		}
+		pathFilter = resolved
+	}
+
+	entries, err := t.jr.List(pathFilter, limit)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error reading journal: %v", err), true)
+	}
+	if len(entries) == 0 {
+		return protocol.TextResult("No recorded mutations", false)
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		change := "modified"
+		if !e.Existed {
+			change = "created"
+		}
+		fmt.Fprintf(&b, "%s  id=%s  %s %s  (request %s, %d byte(s))\n",
+			e.Timestamp.Format("2006-01-02T15:04:05Z"), e.ID, change, e.Path, e.RequestID, e.Size)
+	}
+	return protocol.TextResult(strings.TrimRight(b.String(), "\n"), false)
+}
@@ -2,9 +2,15 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/tgoai/tgo-device-agent/internal/config"
 	"github.com/tgoai/tgo-device-agent/internal/protocol"
@@ -15,11 +21,16 @@ import (
 type FSRead struct {
 	sb  *sandbox.Sandbox
 	cfg *config.Config
+	log *slog.Logger
 }
 
-// NewFSRead creates a new FSRead tool.
-func NewFSRead(sb *sandbox.Sandbox, cfg *config.Config) *FSRead {
-	return &FSRead{sb: sb, cfg: cfg}
+// NewFSRead creates a new FSRead tool. log is the "fs" tool group's logger
+// (see internal/logging.SetupLoggers); it defaults to slog.Default() if nil.
+func NewFSRead(sb *sandbox.Sandbox, cfg *config.Config, log *slog.Logger) *FSRead {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &FSRead{sb: sb, cfg: cfg, log: log}
 }
 
 func (t *FSRead) Name() string { return "fs_read" }
@@ -27,41 +38,68 @@ func (t *FSRead) Name() string { return "fs_read" }
 func (t *FSRead) Definition() protocol.ToolDefinition {
 	return protocol.ToolDefinition{
 		Name:        "fs_read",
-		Description: "Read the contents of a file. Supports optional line offset and limit for partial reads. Returns the file content as text.",
+		Description: "Read the contents of a file. Supports optional line offset and limit for partial reads of text. Text files are returned as text; images and other binary files are returned as base64 image/blob content items (see 'as'). If 'path' is a directory, set mode to 'tar' or 'tar+gzip' to read the whole tree as an archive.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"path": map[string]interface{}{
 					"type":        "string",
-					"description": "Absolute or relative path to the file to read",
+					"description": "Absolute or relative path to the file (or directory, in tar mode) to read",
 				},
 				"offset": map[string]interface{}{
 					"type":        "integer",
-					"description": "Line number to start reading from (1-based). If negative, counts from end. Optional.",
+					"description": "Line number to start reading from (1-based). If negative, counts from end. Optional. Ignored in tar mode.",
 				},
 				"limit": map[string]interface{}{
 					"type":        "integer",
-					"description": "Maximum number of lines to read. Optional – reads entire file if omitted.",
+					"description": "Maximum number of lines to read. Optional – reads entire file if omitted. Ignored in tar mode.",
 				},
 				"encoding": map[string]interface{}{
 					"type":        "string",
 					"description": "File encoding. Default: utf-8",
 					"default":     "utf-8",
 				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "'text' (default) reads a single file. 'tar' or 'tar+gzip' reads 'path' as a directory tree, returned as a base64 blob content item.",
+					"enum":        []string{"text", "tar", "tar+gzip"},
+					"default":     "text",
+				},
+				"include": map[string]interface{}{
+					"type":        "array",
+					"description": "Glob patterns (relative to 'path'); only matching entries are archived. Tar mode only.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"exclude": map[string]interface{}{
+					"type":        "array",
+					"description": "Glob patterns (relative to 'path') to omit from the archive. Tar mode only.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"follow_symlinks": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Archive the target of symlinks instead of the link itself. Tar mode only. Default: false",
+					"default":     false,
+				},
+				"as": map[string]interface{}{
+					"type":        "string",
+					"description": "How to interpret a single file's bytes: 'auto' (default) detects text vs. image vs. other binary; 'text' always decodes as UTF-8 text; 'binary' always returns an image/blob content item.",
+					"enum":        []string{"auto", "text", "binary"},
+					"default":     "auto",
+				},
 			},
 			"required": []string{"path"},
 		},
 	}
 }
 
-func (t *FSRead) Execute(_ context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+func (t *FSRead) Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult {
 	path, _ := args["path"].(string)
 	if path == "" {
 		return protocol.TextResult("Error: 'path' argument is required", true)
 	}
 
 	// Sandbox validation
-	resolved, err := t.sb.ResolvePath(path)
+	resolved, err := t.sb.ResolvePath(ctx, path)
 	if err != nil {
 		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
@@ -74,8 +112,20 @@ func (t *FSRead) Execute(_ context.Context, args map[string]interface{}) *protoc
 		}
 		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
+
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = "text"
+	}
+
 	if info.IsDir() {
-		return protocol.TextResult(fmt.Sprintf("Error: '%s' is a directory, not a file", path), true)
+		if mode != "tar" && mode != "tar+gzip" {
+			return protocol.TextResult(fmt.Sprintf("Error: '%s' is a directory, not a file. Use mode: \"tar\" to read a directory tree.", path), true)
+		}
+		return t.readArchive(resolved, mode, args)
+	}
+	if mode == "tar" || mode == "tar+gzip" {
+		return protocol.TextResult(fmt.Sprintf("Error: '%s' is not a directory", path), true)
 	}
 	if info.Size() > t.cfg.MaxReadBytes {
 		return protocol.TextResult(
@@ -90,12 +140,34 @@ func (t *FSRead) Execute(_ context.Context, args map[string]interface{}) *protoc
 		return protocol.TextResult(fmt.Sprintf("Error reading file: %v", err), true)
 	}
 
-	content := string(data)
+	asMode, _ := args["as"].(string)
+	if asMode == "" {
+		asMode = "auto"
+	}
+	kind, mimeType := classifyContent(resolved, data, asMode)
 
-	// Apply offset/limit if provided
 	offset := intArg(args, "offset", 0)
 	limit := intArg(args, "limit", 0)
 
+	if kind != "text" {
+		if offset != 0 || limit > 0 {
+			return protocol.TextResult("Error: 'offset'/'limit' only apply when the file is read as text", true)
+		}
+		if kind == "image" {
+			return &protocol.ToolCallResult{
+				Content: []protocol.ContentItem{{
+					Type:     "image",
+					Data:     base64.StdEncoding.EncodeToString(data),
+					MimeType: mimeType,
+				}},
+			}
+		}
+		return protocol.BlobResult(data, mimeType)
+	}
+
+	content := string(data)
+
+	// Apply offset/limit if provided
 	if offset != 0 || limit > 0 {
 		lines := strings.Split(content, "\n")
 		totalLines := len(lines)
@@ -132,6 +204,75 @@ func (t *FSRead) Execute(_ context.Context, args map[string]interface{}) *protoc
 	return protocol.TextResult(content, false)
 }
 
+// readArchive implements fs_read's "tar"/"tar+gzip" mode: it walks resolved
+// (already sandbox-validated and known to be a directory) into a tar stream
+// and returns it as a base64 "blob" content item.
+func (t *FSRead) readArchive(resolved, mode string, args map[string]interface{}) *protocol.ToolCallResult {
+	gzipped := mode == "tar+gzip"
+	include := stringSliceArg(args, "include")
+	exclude := stringSliceArg(args, "exclude")
+	followSymlinks := boolArg(args, "follow_symlinks", false)
+
+	data, err := archiveDir(resolved, gzipped, include, exclude, followSymlinks)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error archiving directory: %v", err), true)
+	}
+	if int64(len(data)) > t.cfg.MaxReadBytes {
+		return protocol.TextResult(
+			fmt.Sprintf("Error: archive too large (%d bytes, max %d bytes)", len(data), t.cfg.MaxReadBytes),
+			true,
+		)
+	}
+
+	mimeType := "application/x-tar"
+	if gzipped {
+		mimeType = "application/gzip"
+	}
+	return protocol.BlobResult(data, mimeType)
+}
+
+// classifyContent decides how a single file's bytes should be surfaced to
+// the caller: as "text", "image", or "blob". asMode overrides detection
+// ("text" or "binary"); "auto" (or "") infers from net/http's content
+// sniffing plus a UTF-8 validity check, falling back to the file extension
+// for the reported mimeType when sniffing only yields a generic type.
+func classifyContent(path string, data []byte, asMode string) (kind, mimeType string) {
+	sniffLen := len(data)
+	if sniffLen > 512 {
+		sniffLen = 512
+	}
+	detected := http.DetectContentType(data[:sniffLen])
+	base := detected
+	if i := strings.Index(base, ";"); i >= 0 {
+		base = base[:i]
+	}
+
+	mimeType = detected
+	if ext := filepath.Ext(path); ext != "" {
+		if byExt := mime.TypeByExtension(ext); byExt != "" && (base == "application/octet-stream" || base == "text/plain") {
+			mimeType = byExt
+		}
+	}
+
+	switch asMode {
+	case "text":
+		return "text", mimeType
+	case "binary":
+		if strings.HasPrefix(base, "image/") {
+			return "image", mimeType
+		}
+		return "blob", mimeType
+	default:
+		if strings.HasPrefix(base, "text/") || utf8.Valid(data) {
+			return "text", mimeType
+		}
+		if strings.HasPrefix(base, "image/") {
+			return "image", mimeType
+		}
+		return "blob", mimeType
+	}
+}
+
 // intArg safely extracts an int argument from the args map.
 func intArg(args map[string]interface{}, key string, def int) int {
 	v, ok := args[key]
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/tgoai/tgo-device-agent/internal/journal"
+	"github.com/tgoai/tgo-device-agent/internal/protocol"
+	"github.com/tgoai/tgo-device-agent/internal/sandbox"
+)
+
+// FSRevert implements the fs_revert tool – restores a file (or every file
+// touched by a request) to its pre-mutation state using the journal.
+type FSRevert struct {
+	sb  *sandbox.Sandbox
+	jr  *journal.Journal
+	log *slog.Logger
+}
+
+// NewFSRevert creates a new FSRevert tool. log is the "fs" tool group's
+// logger (see internal/logging.SetupLoggers); it defaults to
+// slog.Default() if nil.
+func NewFSRevert(sb *sandbox.Sandbox, jr *journal.Journal, log *slog.Logger) *FSRevert {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &FSRevert{sb: sb, jr: jr, log: log}
+}
+
+func (t *FSRevert) Name() string { return "fs_revert" }
+
+func (t *FSRevert) Definition() protocol.ToolDefinition {
+	return protocol.ToolDefinition{
+		Name:        "fs_revert",
+		Description: "Undo a prior fs_write/fs_edit mutation recorded in the journal (see fs_history). Give either 'entry_id' to restore one specific mutation, or 'request_id' to roll back every mutation made while handling a given request.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"entry_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Journal entry ID from fs_history to restore",
+				},
+				"request_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Roll back every journal entry recorded for this request ID",
+				},
+			},
+		},
+	}
+}
+
+func (t *FSRevert) Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+	entryID, _ := args["entry_id"].(string)
+	requestID, _ := args["request_id"].(string)
+
+	switch {
+	case entryID != "":
+		entry, err := t.jr.Revert(ctx, entryID, t.sb)
+		if err != nil {
+			return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
+		}
+		t.log.InfoContext(ctx, "fs_revert: reverted entry", "entry_id", entry.ID, "path", entry.Path)
+		return protocol.TextResult(fmt.Sprintf("Reverted %s to its state before entry %s", entry.Path, entry.ID), false)
+
+	case requestID != "":
+		paths, err := t.jr.RevertRequest(ctx, requestID, t.sb)
+		if err != nil {
+			return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
+		}
+		t.log.InfoContext(ctx, "fs_revert: reverted request", "request_id", requestID, "file_count", len(paths))
+		return protocol.TextResult(
+			fmt.Sprintf("Reverted %d file(s) from request %s:\n  %s", len(paths), requestID, strings.Join(paths, "\n  ")),
+			false,
+		)
+
+	default:
+		return protocol.TextResult("Error: either 'entry_id' or 'request_id' is required", true)
+	}
+}
@@ -2,11 +2,14 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/journal"
 	"github.com/tgoai/tgo-device-agent/internal/protocol"
 	"github.com/tgoai/tgo-device-agent/internal/sandbox"
 )
@@ -15,11 +18,18 @@ import (
 type FSWrite struct {
 	sb  *sandbox.Sandbox
 	cfg *config.Config
+	jr  *journal.Journal // nil disables journaling (fs_history/fs_revert unavailable)
+	log *slog.Logger
 }
 
-// NewFSWrite creates a new FSWrite tool.
-func NewFSWrite(sb *sandbox.Sandbox, cfg *config.Config) *FSWrite {
-	return &FSWrite{sb: sb, cfg: cfg}
+// NewFSWrite creates a new FSWrite tool. jr may be nil, in which case
+// writes are not journaled. log is the "fs" tool group's logger (see
+// internal/logging.SetupLoggers); it defaults to slog.Default() if nil.
+func NewFSWrite(sb *sandbox.Sandbox, cfg *config.Config, jr *journal.Journal, log *slog.Logger) *FSWrite {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &FSWrite{sb: sb, cfg: cfg, jr: jr, log: log}
 }
 
 func (t *FSWrite) Name() string { return "fs_write" }
@@ -27,22 +37,22 @@ func (t *FSWrite) Name() string { return "fs_write" }
 func (t *FSWrite) Definition() protocol.ToolDefinition {
 	return protocol.ToolDefinition{
 		Name:        "fs_write",
-		Description: "Write content to a file. Creates the file and parent directories if they do not exist. Supports overwrite and append modes.",
+		Description: "Write content to a file. Creates the file and parent directories if they do not exist. Supports overwrite and append modes. Set mode to 'tar' or 'tar+gzip' to extract a base64-encoded archive into 'path' as a directory instead.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"path": map[string]interface{}{
 					"type":        "string",
-					"description": "Absolute or relative path to the file to write",
+					"description": "Absolute or relative path to the file (or destination directory, in tar mode) to write",
 				},
 				"content": map[string]interface{}{
 					"type":        "string",
-					"description": "Content to write to the file",
+					"description": "Content to write to the file. In tar mode, a base64-encoded tar (or tar+gzip) archive.",
 				},
 				"mode": map[string]interface{}{
 					"type":        "string",
-					"description": "Write mode: 'overwrite' (default) or 'append'",
-					"enum":        []string{"overwrite", "append"},
+					"description": "Write mode: 'overwrite' (default), 'append', 'tar', or 'tar+gzip'",
+					"enum":        []string{"overwrite", "append", "tar", "tar+gzip"},
 					"default":     "overwrite",
 				},
 				"create_dirs": map[string]interface{}{
@@ -56,7 +66,7 @@ func (t *FSWrite) Definition() protocol.ToolDefinition {
 	}
 }
 
-func (t *FSWrite) Execute(_ context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+func (t *FSWrite) Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult {
 	path, _ := args["path"].(string)
 	content, _ := args["content"].(string)
 
@@ -64,6 +74,15 @@ func (t *FSWrite) Execute(_ context.Context, args map[string]interface{}) *proto
 		return protocol.TextResult("Error: 'path' argument is required", true)
 	}
 
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = "overwrite"
+	}
+
+	if mode == "tar" || mode == "tar+gzip" {
+		return t.writeArchive(ctx, path, content, mode)
+	}
+
 	// Check content size
 	if int64(len(content)) > t.cfg.MaxWriteBytes {
 		return protocol.TextResult(
@@ -73,12 +92,12 @@ func (t *FSWrite) Execute(_ context.Context, args map[string]interface{}) *proto
 	}
 
 	// Sandbox validation
-	resolved, err := t.sb.ResolvePath(path)
+	resolved, err := t.sb.ResolvePath(ctx, path)
 	if err != nil {
 		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
 
-	if err := t.sb.ValidateWrite(resolved); err != nil {
+	if err := t.sb.ValidateWrite(ctx, resolved); err != nil {
 		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
 
@@ -91,11 +110,7 @@ func (t *FSWrite) Execute(_ context.Context, args map[string]interface{}) *proto
 		}
 	}
 
-	// Determine file mode
-	mode, _ := args["mode"].(string)
-	if mode == "" {
-		mode = "overwrite"
-	}
+	t.journal(ctx, resolved)
 
 	var flag int
 	switch mode {
@@ -122,6 +137,59 @@ func (t *FSWrite) Execute(_ context.Context, args map[string]interface{}) *proto
 	)
 }
 
+// writeArchive implements fs_write's "tar"/"tar+gzip" mode: it decodes a
+// base64 tar archive from content and extracts it into path, which is
+// treated as a destination directory rather than a single file. Each
+// extracted regular file is journaled the same way the plain-write path is,
+// so a tar/tar+gzip write is just as revertable via fs_history/fs_revert.
+func (t *FSWrite) writeArchive(ctx context.Context, path, content, mode string) *protocol.ToolCallResult {
+	resolvedRoot, err := t.sb.ResolvePath(ctx, path)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
+	}
+	if err := t.sb.ValidateWrite(ctx, resolvedRoot); err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
+	}
+	if err := os.MkdirAll(resolvedRoot, 0o755); err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error creating directory: %v", err), true)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error: content is not valid base64: %v", err), true)
+	}
+
+	written, paths, err := extractTar(ctx, t.sb, resolvedRoot, data, mode == "tar+gzip", t.cfg.MaxWriteBytes, t.journal)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error extracting archive: %v", err), true)
+	}
+
+	return protocol.TextResult(
+		fmt.Sprintf("Successfully extracted %d file(s) (%d bytes) into %s", len(paths), written, path),
+		false,
+	)
+}
+
+// journal records resolved's pre-write content, if journaling is enabled.
+// Failures are logged and otherwise ignored – a lost journal entry should
+// never fail the write it's trying to make undoable.
+func (t *FSWrite) journal(ctx context.Context, resolved string) {
+	if t.jr == nil {
+		return
+	}
+
+	original, err := os.ReadFile(resolved)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		t.log.WarnContext(ctx, "fs_write: failed to read pre-write content for journal", "path", resolved, "error", err)
+		return
+	}
+
+	if _, err := t.jr.Record("fs_write", resolved, protocol.RequestIDFromContext(ctx), original, existed); err != nil {
+		t.log.WarnContext(ctx, "fs_write: failed to record journal entry", "path", resolved, "error", err)
+	}
+}
+
 // boolArg safely extracts a bool argument from the args map.
 func boolArg(args map[string]interface{}, key string, def bool) bool {
 	v, ok := args[key]
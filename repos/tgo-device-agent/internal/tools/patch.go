@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkFuzz is how many lines of drift locateBlock tolerates when looking
+// for a hunk's context in the target file, so a patch generated against a
+// slightly older version of the file can still apply.
+const hunkFuzz = 3
+
+// filePatch is one file's worth of a parsed unified diff: its old/new path
+// (as recorded in the "---"/"+++" header) and the hunks to apply to it.
+// isCreate/isDelete come from a "/dev/null" old or new path respectively,
+// the unified-diff convention for "this file didn't exist before"/"doesn't
+// exist after".
+type filePatch struct {
+	path     string
+	isCreate bool
+	isDelete bool
+	hunks    []diffHunk
+}
+
+// diffHunk is one "@@ -a,b +c,d @@" hunk: its recorded old/new line ranges
+// and the context/add/remove lines in between.
+type diffHunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	lines              []hunkLine
+}
+
+func (h diffHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.oldStart, h.oldLines, h.newStart, h.newLines)
+}
+
+// hunkLine is a single line of a hunk body: kind is ' ' (context), '-'
+// (removed), or '+' (added).
+type hunkLine struct {
+	kind byte
+	text string
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch parses a unified diff (as produced by `diff -u` or `git diff`)
+// covering one or more files into their constituent hunks. It tolerates a
+// leading "diff --git"/"index" line before each file's "---"/"+++" header
+// but does not require it.
+func parsePatch(patch string) ([]*filePatch, error) {
+	lines := strings.Split(patch, "\n")
+	var files []*filePatch
+	var cur *filePatch
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "), strings.HasPrefix(line, "index "):
+			i++
+		case strings.HasPrefix(line, "--- "):
+			if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+				return nil, fmt.Errorf("malformed patch: '--- ' header without a following '+++ ' at line %d", i+1)
+			}
+			oldPath := stripDiffPrefix(strings.TrimPrefix(line, "--- "))
+			newPath := stripDiffPrefix(strings.TrimPrefix(lines[i+1], "+++ "))
+			cur = &filePatch{
+				path:     newPath,
+				isCreate: oldPath == "/dev/null",
+				isDelete: newPath == "/dev/null",
+			}
+			if cur.isDelete {
+				cur.path = oldPath
+			}
+			files = append(files, cur)
+			i += 2
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("hunk header at line %d has no preceding file header", i+1)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.hunks = append(cur.hunks, hunk)
+			i = next
+		default:
+			i++
+		}
+	}
+	return files, nil
+}
+
+// stripDiffPrefix trims the "a/"/"b/" prefix git diff adds to paths and any
+// trailing tab-separated timestamp, leaving "/dev/null" untouched.
+func stripDiffPrefix(p string) string {
+	p = strings.TrimRight(p, "\r")
+	if idx := strings.IndexByte(p, '\t'); idx >= 0 {
+		p = p[:idx]
+	}
+	if p == "/dev/null" {
+		return p
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+// parseHunk parses the hunk header at lines[start] and its body, stopping
+// at the next hunk/file header or end of input. It returns the index of
+// the line after the hunk.
+func parseHunk(lines []string, start int) (diffHunk, int, error) {
+	m := hunkHeaderRe.FindStringSubmatch(lines[start])
+	if m == nil {
+		return diffHunk{}, 0, fmt.Errorf("malformed hunk header: %q", lines[start])
+	}
+	h := diffHunk{
+		oldStart: atoiDefault(m[1], 0),
+		oldLines: atoiDefault(m[2], 1),
+		newStart: atoiDefault(m[3], 0),
+		newLines: atoiDefault(m[4], 1),
+	}
+
+	i := start + 1
+	for i < len(lines) {
+		line := lines[i]
+		if strings.HasPrefix(line, "@@ ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "diff --git ") {
+			break
+		}
+		if strings.HasPrefix(line, `\ No newline at end of file`) {
+			i++
+			continue
+		}
+		if line == "" {
+			// A trailing blank line from splitting the patch text; only a
+			// body line if there's more hunk content after it.
+			if i == len(lines)-1 {
+				break
+			}
+			h.lines = append(h.lines, hunkLine{kind: ' ', text: ""})
+			i++
+			continue
+		}
+		switch line[0] {
+		case ' ', '+', '-':
+			h.lines = append(h.lines, hunkLine{kind: line[0], text: line[1:]})
+		default:
+			// Some diff tools trim the leading space off blank context
+			// lines; treat an unrecognized prefix as context verbatim.
+			h.lines = append(h.lines, hunkLine{kind: ' ', text: line})
+		}
+		i++
+	}
+	return h, i, nil
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// hunkResult records whether one hunk was successfully located and applied.
+type hunkResult struct {
+	header  string
+	applied bool
+	reason  string
+}
+
+// applyHunks applies hunks, in order, to original's content and returns the
+// patched content plus a per-hunk result. Hunks must apply in increasing
+// line order; a hunk whose context can't be located (even after widening
+// the search by hunkFuzz lines) is reported as unapplied in its result
+// rather than aborting the rest. A hunk is never placed before the previous
+// hunk's end, so one whose context only matches there (an overlap) is
+// reported as not located.
+func applyHunks(original string, hunks []diffHunk) (string, []hunkResult, error) {
+	lines := splitLines(original)
+	hadTrailingNewline := original == "" || strings.HasSuffix(original, "\n")
+
+	var out []string
+	cursor := 0
+	results := make([]hunkResult, 0, len(hunks))
+
+	for _, h := range hunks {
+		oldBlock := make([]string, 0, len(h.lines))
+		newBlock := make([]string, 0, len(h.lines))
+		for _, l := range h.lines {
+			switch l.kind {
+			case ' ':
+				oldBlock = append(oldBlock, l.text)
+				newBlock = append(newBlock, l.text)
+			case '-':
+				oldBlock = append(oldBlock, l.text)
+			case '+':
+				newBlock = append(newBlock, l.text)
+			}
+		}
+
+		pos, err := locateBlock(lines, h.oldStart-1, oldBlock, cursor, hunkFuzz)
+		if err != nil {
+			results = append(results, hunkResult{header: h.header(), reason: err.Error()})
+			continue
+		}
+
+		out = append(out, lines[cursor:pos]...)
+		out = append(out, newBlock...)
+		cursor = pos + len(oldBlock)
+		results = append(results, hunkResult{header: h.header(), applied: true})
+	}
+	out = append(out, lines[cursor:]...)
+
+	content := strings.Join(out, "\n")
+	if hadTrailingNewline && content != "" {
+		content += "\n"
+	}
+	return content, results, nil
+}
+
+// locateBlock finds block as a contiguous run within lines, starting the
+// search at expected (0-based) and widening outward by up to fuzz lines in
+// either direction. A match at or after minPos is required, so a hunk can
+// never be placed earlier than the previous hunk it was applied after.
+func locateBlock(lines []string, expected int, block []string, minPos, fuzz int) (int, error) {
+	if len(block) == 0 {
+		if expected < minPos {
+			expected = minPos
+		}
+		if expected > len(lines) {
+			expected = len(lines)
+		}
+		return expected, nil
+	}
+	tried := map[int]bool{}
+	for d := 0; d <= fuzz; d++ {
+		for _, cand := range []int{expected - d, expected + d} {
+			if tried[cand] || cand < minPos || cand+len(block) > len(lines) {
+				tried[cand] = true
+				continue
+			}
+			tried[cand] = true
+			if blockMatches(lines, cand, block) {
+				return cand, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("context not found near line %d (searched ±%d lines)", expected+1, fuzz)
+}
+
+func blockMatches(lines []string, at int, block []string) bool {
+	for i, want := range block {
+		if lines[at+i] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLines splits s into lines without a trailing empty element for a
+// final "\n" – callers track whether the original had one separately.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
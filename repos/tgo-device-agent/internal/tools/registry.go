@@ -1,5 +1,5 @@
 // Package tools provides the tool registry and built-in tool implementations
-// (fs_read, fs_write, fs_edit, shell_exec).
+// (fs_read, fs_write, fs_edit, fs_history, fs_revert, shell_exec).
 package tools
 
 import (
@@ -8,6 +8,9 @@ import (
 	"log/slog"
 
 	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/journal"
+	"github.com/tgoai/tgo-device-agent/internal/logging"
+	"github.com/tgoai/tgo-device-agent/internal/observability"
 	"github.com/tgoai/tgo-device-agent/internal/protocol"
 	"github.com/tgoai/tgo-device-agent/internal/sandbox"
 )
@@ -22,29 +25,91 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult
 }
 
+// Streamer is implemented by tools that can emit incremental output while
+// running (e.g. long shell commands) instead of only returning a result
+// once they finish. emit is called once per chunk with the stream name
+// ("stdout" or "stderr") and the chunk text; the dispatcher forwards each
+// call as a "tools/progress" notification.
+type Streamer interface {
+	ExecuteStreaming(ctx context.Context, args map[string]interface{}, emit func(stream, chunk string)) *protocol.ToolCallResult
+}
+
 // Registry holds registered tools and dispatches calls.
 type Registry struct {
-	tools map[string]Tool
-	order []string // preserve registration order for listing
+	tools   map[string]Tool
+	order   []string // preserve registration order for listing
+	metrics observability.Metrics
 }
 
-// NewRegistry creates a Registry with all built-in tools registered.
-func NewRegistry(cfg *config.Config) *Registry {
-	sb := sandbox.New(cfg)
+// NewRegistry creates a Registry with all built-in tools registered,
+// subject to cfg.Tools' per-group Enabled flags and overrides. metrics may
+// be nil, in which case tool calls simply aren't recorded. loggers is the
+// result of logging.SetupLoggers (or nil), used to give each tool group its
+// own logger; a group with no entry falls back to slog.Default().
+func NewRegistry(cfg *config.Config, metrics observability.Metrics, loggers map[string]*slog.Logger) *Registry {
 	r := &Registry{
-		tools: make(map[string]Tool),
+		tools:   make(map[string]Tool),
+		metrics: metrics,
+	}
+
+	fsCfg := cfg
+	fsTC := cfg.ToolGroup("fs")
+	if fsTC.WorkRoot != "" {
+		fsCfg = cfgForGroup(cfg, fsTC)
+	}
+	fsSB := sandbox.New(fsCfg)
+	fsLog := logging.LoggerFor(loggers, "fs")
+
+	// The journal backs fs_history/fs_revert's "undo" primitive. A failure
+	// to create it (e.g. an unwritable work root) disables journaling and
+	// those two tools rather than failing agent startup.
+	jr, err := journal.New(fsSB, fsCfg)
+	if err != nil {
+		slog.Warn("journal unavailable, fs_write/fs_edit will not be undoable", "error", err)
+		jr = nil
+	}
+
+	if fsTC.Enabled {
+		r.Register(NewFSRead(fsSB, fsCfg, fsLog))
+		r.Register(NewFSWrite(fsSB, fsCfg, jr, fsLog))
+		r.Register(NewFSEdit(fsSB, fsCfg, jr, fsLog))
+		if jr != nil {
+			r.Register(NewFSHistory(fsSB, jr, fsLog))
+			r.Register(NewFSRevert(fsSB, jr, fsLog))
+		}
 	}
 
-	// Register built-in tools
-	r.Register(NewFSRead(sb, cfg))
-	r.Register(NewFSWrite(sb, cfg))
-	r.Register(NewFSEdit(sb, cfg))
-	r.Register(NewShellExec(sb, cfg))
+	shellTC := cfg.ToolGroup("shell")
+	if shellTC.Enabled {
+		shellCfg := cfg
+		if len(shellTC.AllowedShells) > 0 || len(shellTC.BlockedCommands) > 0 {
+			shellCfg = cfgForGroup(cfg, shellTC)
+		}
+		r.Register(NewShellExec(sandbox.New(shellCfg), shellCfg, logging.LoggerFor(loggers, "shell")))
+	}
 
 	slog.Info("tool registry initialized", "tool_count", len(r.tools))
 	return r
 }
 
+// cfgForGroup returns a shallow copy of cfg with tc's non-empty overrides
+// (WorkRoot, AllowedShells, BlockedCommands) applied, so a tool group's
+// sandbox.Sandbox can be built from group-specific settings without
+// mutating the shared top-level Config.
+func cfgForGroup(cfg *config.Config, tc config.ToolConfig) *config.Config {
+	clone := *cfg
+	if tc.WorkRoot != "" {
+		clone.WorkRoot = tc.WorkRoot
+	}
+	if len(tc.AllowedShells) > 0 {
+		clone.AllowedShells = tc.AllowedShells
+	}
+	if len(tc.BlockedCommands) > 0 {
+		clone.BlockedCommands = tc.BlockedCommands
+	}
+	return &clone
+}
+
 // Register adds a tool to the registry.
 func (r *Registry) Register(t Tool) {
 	name := t.Name()
@@ -66,10 +131,51 @@ func (r *Registry) ListTools() []protocol.ToolDefinition {
 func (r *Registry) CallTool(ctx context.Context, name string, args map[string]interface{}) *protocol.ToolCallResult {
 	t, ok := r.tools[name]
 	if !ok {
-		return protocol.TextResult(
+		result := protocol.TextResult(
 			fmt.Sprintf("Error: tool '%s' not found. Available tools: %v", name, r.order),
 			true,
 		)
+		r.recordToolCall(name, result)
+		return result
+	}
+	result := t.Execute(ctx, args)
+	r.recordToolCall(name, result)
+	return result
+}
+
+// CallToolStreaming dispatches a tool call by name, using the tool's
+// streaming path if it implements Streamer so emit is invoked for each
+// incremental chunk; otherwise it falls back to Execute and emit is never
+// called.
+func (r *Registry) CallToolStreaming(ctx context.Context, name string, args map[string]interface{}, emit func(stream, chunk string)) *protocol.ToolCallResult {
+	t, ok := r.tools[name]
+	if !ok {
+		result := protocol.TextResult(
+			fmt.Sprintf("Error: tool '%s' not found. Available tools: %v", name, r.order),
+			true,
+		)
+		r.recordToolCall(name, result)
+		return result
+	}
+
+	var result *protocol.ToolCallResult
+	if s, ok := t.(Streamer); ok {
+		result = s.ExecuteStreaming(ctx, args, emit)
+	} else {
+		result = t.Execute(ctx, args)
+	}
+	r.recordToolCall(name, result)
+	return result
+}
+
+// recordToolCall reports a completed tool call to metrics, if configured.
+func (r *Registry) recordToolCall(name string, result *protocol.ToolCallResult) {
+	if r.metrics == nil {
+		return
+	}
+	status := "ok"
+	if result.IsError {
+		status = "error"
 	}
-	return t.Execute(ctx, args)
+	r.metrics.ToolCall(name, status)
 }
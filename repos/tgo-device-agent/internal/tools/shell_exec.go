@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -17,11 +20,17 @@ import (
 type ShellExec struct {
 	sb  *sandbox.Sandbox
 	cfg *config.Config
+	log *slog.Logger
 }
 
-// NewShellExec creates a new ShellExec tool.
-func NewShellExec(sb *sandbox.Sandbox, cfg *config.Config) *ShellExec {
-	return &ShellExec{sb: sb, cfg: cfg}
+// NewShellExec creates a new ShellExec tool. log is the "shell" tool
+// group's logger (see internal/logging.SetupLoggers); it defaults to
+// slog.Default() if nil.
+func NewShellExec(sb *sandbox.Sandbox, cfg *config.Config, log *slog.Logger) *ShellExec {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &ShellExec{sb: sb, cfg: cfg, log: log}
 }
 
 func (t *ShellExec) Name() string { return "shell_exec" }
@@ -56,14 +65,88 @@ func (t *ShellExec) Definition() protocol.ToolDefinition {
 }
 
 func (t *ShellExec) Execute(ctx context.Context, args map[string]interface{}) *protocol.ToolCallResult {
+	prep, errResult := t.prepare(ctx, args)
+	if errResult != nil {
+		return errResult
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, prep.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	exitCode, timedOut, err := t.run(cmdCtx, prep, &stdout, &stderr)
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error executing command: %v", err), true)
+	}
+
+	stdoutStr := truncate(stdout.String(), t.cfg.MaxOutputBytes)
+	stderrStr := truncate(stderr.String(), t.cfg.MaxOutputBytes)
+
+	res := protocol.TextResult(formatResult(stdoutStr, stderrStr, exitCode, timedOut, prep.timeout), exitCode != 0)
+	res.ExitCode = &exitCode
+	return res
+}
+
+// ExecuteStreaming runs the command like Execute but emits incremental
+// stdout/stderr chunks via emit as they arrive instead of only returning a
+// result once the command finishes. The final result still carries the
+// captured output, exit code, and truncation status, so a caller that
+// ignores the emitted chunks still gets the whole outcome in one response.
+// Unlike Execute, MaxOutputBytes caps stdout and stderr combined rather than
+// each stream, and truncation is reported in Truncated rather than by an
+// inline "[output truncated]" notice.
+func (t *ShellExec) ExecuteStreaming(ctx context.Context, args map[string]interface{}, emit func(stream, chunk string)) *protocol.ToolCallResult {
+	prep, errResult := t.prepare(ctx, args)
+	if errResult != nil {
+		return errResult
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, prep.timeout)
+	defer cancel()
+
+	budget := &cumulativeCap{max: t.cfg.MaxOutputBytes}
+	var stdoutBuf, stderrBuf bytes.Buffer
+	stdout := newLineFlushWriter(&stdoutBuf, "stdout", emit, budget)
+	stderr := newLineFlushWriter(&stderrBuf, "stderr", emit, budget)
+	stdout.startTicker()
+	stderr.startTicker()
+
+	exitCode, timedOut, err := t.run(cmdCtx, prep, stdout, stderr)
+	stdout.stopTicker()
+	stderr.stopTicker()
+	stdout.flush()
+	stderr.flush()
+	if err != nil {
+		return protocol.TextResult(fmt.Sprintf("Error executing command: %v", err), true)
+	}
+
+	res := protocol.TextResult(formatResult(stdoutBuf.String(), stderrBuf.String(), exitCode, timedOut, prep.timeout), exitCode != 0)
+	res.ExitCode = &exitCode
+	res.Truncated = budget.truncated()
+	return res
+}
+
+// preparedCmd holds the resolved inputs for a single shell_exec invocation,
+// shared between Execute and ExecuteStreaming.
+type preparedCmd struct {
+	shell   string
+	command string
+	cwd     string
+	env     []string
+	timeout time.Duration
+}
+
+// prepare validates arguments and resolves the shell, working directory,
+// environment, and timeout for a command. On failure it returns a non-nil
+// error result ready to hand straight back to the caller.
+func (t *ShellExec) prepare(ctx context.Context, args map[string]interface{}) (preparedCmd, *protocol.ToolCallResult) {
 	command, _ := args["command"].(string)
 	if command == "" {
-		return protocol.TextResult("Error: 'command' argument is required", true)
+		return preparedCmd{}, protocol.TextResult("Error: 'command' argument is required", true)
 	}
 
 	// Security: check blocked commands
-	if err := t.sb.ValidateCommand(command); err != nil {
-		return protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
+	if err := t.sb.ValidateCommand(ctx, command); err != nil {
+		t.log.WarnContext(ctx, "shell_exec: command denied", "command", command, "error", err)
+		return preparedCmd{}, protocol.TextResult(fmt.Sprintf("Error: %v", err), true)
 	}
 
 	// Timeout
@@ -75,60 +158,47 @@ func (t *ShellExec) Execute(ctx context.Context, args map[string]interface{}) *p
 			timeout = 5 * time.Minute
 		}
 	}
-	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	// Find shell
-	shell := findShell(t.cfg.AllowedShells)
-
-	cmd := exec.CommandContext(cmdCtx, shell, "-c", command)
 
 	// Working directory
 	cwd, _ := args["cwd"].(string)
 	if cwd != "" {
-		resolved, err := t.sb.ResolvePath(cwd)
+		resolved, err := t.sb.ResolvePath(ctx, cwd)
 		if err != nil {
-			return protocol.TextResult(fmt.Sprintf("Error: invalid cwd: %v", err), true)
+			return preparedCmd{}, protocol.TextResult(fmt.Sprintf("Error: invalid cwd: %v", err), true)
 		}
-		cmd.Dir = resolved
+		cwd = resolved
 	} else {
-		cmd.Dir = t.sb.WorkRoot()
+		cwd = t.sb.WorkRoot()
 	}
 
 	// Environment
-	cmd.Env = cmd.Environ()
+	env := os.Environ()
 	if envMap, ok := args["env"].(map[string]interface{}); ok {
 		for k, v := range envMap {
-			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%v", k, v))
+			env = append(env, fmt.Sprintf("%s=%v", k, v))
 		}
 	}
 
-	// Capture output
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	return preparedCmd{
+		shell:   findShell(t.cfg.AllowedShells),
+		command: command,
+		cwd:     cwd,
+		env:     env,
+		timeout: timeout,
+	}, nil
+}
 
-	// Determine exit code
-	exitCode := 0
-	timedOut := false
-	if err != nil {
-		if cmdCtx.Err() == context.DeadlineExceeded {
-			timedOut = true
-			exitCode = -1
-		} else if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else {
-			return protocol.TextResult(fmt.Sprintf("Error executing command: %v", err), true)
-		}
+// run dispatches to the configured exec backend (host or oci).
+func (t *ShellExec) run(ctx context.Context, p preparedCmd, stdout, stderr io.Writer) (exitCode int, timedOut bool, err error) {
+	if t.cfg.ExecBackend == "oci" {
+		return t.runOCI(ctx, p.shell, p.command, p.cwd, p.env, stdout, stderr)
 	}
+	return t.runHost(ctx, p.shell, p.command, p.cwd, p.env, stdout, stderr)
+}
 
-	// Truncate output if needed
-	stdoutStr := truncate(stdout.String(), t.cfg.MaxOutputBytes)
-	stderrStr := truncate(stderr.String(), t.cfg.MaxOutputBytes)
-
-	// Build result
+// formatResult renders stdout/stderr and exit status into the tool's
+// human-readable text content.
+func formatResult(stdoutStr, stderrStr string, exitCode int, timedOut bool, timeout time.Duration) string {
 	var result strings.Builder
 	if timedOut {
 		result.WriteString(fmt.Sprintf("[TIMEOUT after %v]\n", timeout))
@@ -149,8 +219,57 @@ func (t *ShellExec) Execute(ctx context.Context, args map[string]interface{}) *p
 	if timedOut {
 		result.WriteString(" [timed_out: true]")
 	}
+	return result.String()
+}
+
+// runHost executes the command directly on the host via exec.CommandContext –
+// the original behavior, relying on the sandbox's blocked-command list.
+func (t *ShellExec) runHost(ctx context.Context, shell, command, cwd string, env []string, stdout, stderr io.Writer) (exitCode int, timedOut bool, err error) {
+	cmd := exec.CommandContext(ctx, shell, "-c", command)
+	cmd.Dir = cwd
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	runErr := cmd.Run()
+	if runErr == nil {
+		return 0, false, nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return -1, true, nil
+	}
+	if exitErr, ok := runErr.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), false, nil
+	}
+	return 0, false, runErr
+}
+
+// runOCI executes the command inside an isolated OCI-spec container via the
+// configured runtime (runc/crun). If no runtime can be found, it fails
+// closed by default (cfg.OCIRequireRuntime) rather than silently falling
+// back to the unisolated host backend – an operator who asked for OCI
+// isolation should be told it stopped working, not have it quietly
+// downgraded. Setting OCIRequireRuntime to false restores the old
+// fail-open behavior, still with a warning logged either way.
+func (t *ShellExec) runOCI(ctx context.Context, shell, command, cwd string, env []string, stdout, stderr io.Writer) (exitCode int, timedOut bool, err error) {
+	runner, err := sandbox.NewOCIRunner(t.sb, t.cfg)
+	if err != nil {
+		if t.cfg.OCIRequireRuntime {
+			t.log.WarnContext(ctx, "shell_exec: OCI runtime unavailable, refusing to fall back to host exec", "error", err)
+			return 0, false, fmt.Errorf("OCI exec backend configured but no runtime available: %w", err)
+		}
+		t.log.WarnContext(ctx, "shell_exec: OCI runtime unavailable, falling back to host exec", "error", err)
+		return t.runHost(ctx, shell, command, cwd, env, stdout, stderr)
+	}
 
-	return protocol.TextResult(result.String(), exitCode != 0)
+	code, runErr := runner.Run(ctx, t.cfg, shell, command, cwd, env, stdout, stderr)
+	if runErr != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, true, nil
+		}
+		return 0, false, runErr
+	}
+	return code, false, nil
 }
 
 // findShell returns the first available shell from the allowed list.
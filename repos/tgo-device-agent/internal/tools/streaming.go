@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// streamFlushInterval bounds how long output can sit unflushed when no
+// newline has arrived yet (e.g. a progress bar that rewrites a single line).
+const streamFlushInterval = 100 * time.Millisecond
+
+// cumulativeCap enforces a single byte budget shared across multiple
+// writers (a command's stdout and stderr), so MaxOutputBytes caps the
+// combined output rather than each stream independently.
+type cumulativeCap struct {
+	mu       sync.Mutex
+	used     int64
+	max      int64
+	exceeded bool
+}
+
+// take reserves up to n bytes from the budget, returning how many were
+// actually allowed and whether the cap has now been hit.
+func (c *cumulativeCap) take(n int) (allowed int, hitLimit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	remaining := c.max - c.used
+	if remaining <= 0 {
+		c.exceeded = true
+		return 0, true
+	}
+	if int64(n) > remaining {
+		c.used = c.max
+		c.exceeded = true
+		return int(remaining), true
+	}
+	c.used += int64(n)
+	return n, false
+}
+
+func (c *cumulativeCap) truncated() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.exceeded
+}
+
+// lineFlushWriter buffers written bytes into dst (so the full output is
+// still available for the final tools/call response) while also forwarding
+// them to emit in chunks, flushed on a newline or after streamFlushInterval,
+// whichever comes first.
+type lineFlushWriter struct {
+	mu        sync.Mutex
+	dst       *bytes.Buffer
+	stream    string
+	emit      func(stream, chunk string)
+	budget    *cumulativeCap
+	pending   []byte
+	lastFlush time.Time
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+func newLineFlushWriter(dst *bytes.Buffer, stream string, emit func(stream, chunk string), budget *cumulativeCap) *lineFlushWriter {
+	return &lineFlushWriter{dst: dst, stream: stream, emit: emit, budget: budget, lastFlush: time.Now()}
+}
+
+// startTicker begins a background flush every streamFlushInterval, so
+// output that arrives with no trailing newline (e.g. a \r-driven progress
+// line) and is then followed by silence still gets emitted on schedule
+// instead of waiting for the next Write to notice the elapsed time. The
+// caller must call stopTicker exactly once when done with the writer.
+func (w *lineFlushWriter) startTicker() {
+	w.stop = make(chan struct{})
+	w.stopped = make(chan struct{})
+	go func() {
+		defer close(w.stopped)
+		ticker := time.NewTicker(streamFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.flush()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopTicker stops the background flush goroutine started by startTicker
+// and waits for it to exit, so a final flush() call afterward can't race
+// with it.
+func (w *lineFlushWriter) stopTicker() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.stopped
+}
+
+// Write implements io.Writer. It always reports the full length written and
+// a nil error, silently dropping bytes past the cumulative cap – matching
+// the non-streaming truncate() behavior rather than aborting the command.
+func (w *lineFlushWriter) Write(p []byte) (int, error) {
+	allowed, _ := w.budget.take(len(p))
+	accepted := p[:allowed]
+
+	w.mu.Lock()
+	w.dst.Write(accepted)
+	w.pending = append(w.pending, accepted...)
+	shouldFlush := bytes.ContainsRune(accepted, '\n') || time.Since(w.lastFlush) >= streamFlushInterval
+	var chunk []byte
+	if shouldFlush && len(w.pending) > 0 {
+		chunk = w.pending
+		w.pending = nil
+		w.lastFlush = time.Now()
+	}
+	w.mu.Unlock()
+
+	if len(chunk) > 0 && w.emit != nil {
+		w.emit(w.stream, string(chunk))
+	}
+	return len(p), nil
+}
+
+// flush forces out any buffered-but-not-yet-emitted bytes; call once after
+// the command exits so the last partial line isn't lost.
+func (w *lineFlushWriter) flush() {
+	w.mu.Lock()
+	chunk := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+	if len(chunk) > 0 && w.emit != nil {
+		w.emit(w.stream, string(chunk))
+	}
+}
@@ -1,16 +1,15 @@
-// Package transport implements the TCP JSON-RPC client that connects to
-// tgo-device-control, handles authentication, heartbeat, reconnection,
-// and dispatches incoming tool calls to the tool registry.
+// Package transport implements the JSON-RPC client that connects to
+// tgo-device-control over a pluggable wire transport (TCP or WebSocket),
+// handles authentication, heartbeat, reconnection, and dispatches incoming
+// tool calls to the tool registry.
 package transport
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"os"
 	"runtime"
 	"strings"
@@ -18,43 +17,80 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/tgoai/tgo-device-agent/internal/auth"
 	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/discovery"
+	"github.com/tgoai/tgo-device-agent/internal/logctx"
+	"github.com/tgoai/tgo-device-agent/internal/observability"
 	"github.com/tgoai/tgo-device-agent/internal/protocol"
 	"github.com/tgoai/tgo-device-agent/internal/tools"
 )
 
-// Client manages the TCP connection to tgo-device-control.
+// Client manages the connection to tgo-device-control.
 type Client struct {
 	cfg      *config.Config
 	registry *tools.Registry
 
-	conn     net.Conn
-	connMu   sync.Mutex
-	reader   *bufio.Scanner
-	writer   *bufio.Writer
-	writeMu  sync.Mutex
+	// operatorAuth, when non-nil, gates bind-code enrollment on a local
+	// operator confirming the bind with a passphrase. It is nil unless
+	// cfg.OperatorAuthRequired is set.
+	operatorAuth *auth.OperatorAuth
 
-	deviceID  string
-	projectID string
+	// metrics, when non-nil, receives reconnect/connection/token-age
+	// observations; see internal/observability.
+	metrics observability.Metrics
+
+	// resolver supplies the endpoint dial connects to and, via Watch, can
+	// force an early reconnect when the registry reports it's gone.
+	resolver discovery.Resolver
+
+	mc      MessageConn
+	connMu  sync.Mutex
+	writeMu sync.Mutex
+
+	deviceID      string
+	projectID     string
+	tokenIssuedAt time.Time
 
 	requestID atomic.Int64
 	pending   sync.Map // id -> chan *protocol.Response
 }
 
-// NewClient creates a new transport client.
-func NewClient(cfg *config.Config, registry *tools.Registry) *Client {
+// NewClient creates a new transport client. operatorAuth may be nil; it is
+// only consulted when cfg.OperatorAuthRequired is set and a bind code (as
+// opposed to a saved device token) is being used to authenticate. metrics
+// may also be nil, in which case connection/reconnect/token-age metrics
+// simply aren't recorded. resolver may be nil, in which case the client
+// falls back to a static resolver over cfg.ServerHost/cfg.ServerPort –
+// callers that care about cfg.Discovery should build one with
+// discovery.New(cfg) and pass it in instead.
+func NewClient(cfg *config.Config, registry *tools.Registry, operatorAuth *auth.OperatorAuth, metrics observability.Metrics, resolver discovery.Resolver) *Client {
+	if resolver == nil {
+		resolver = discovery.NewStatic(cfg.ServerHost, cfg.ServerPort)
+	}
 	return &Client{
-		cfg:      cfg,
-		registry: registry,
+		cfg:          cfg,
+		registry:     registry,
+		operatorAuth: operatorAuth,
+		metrics:      metrics,
+		resolver:     resolver,
 	}
 }
 
 // Run connects to the server and enters the main loop.
-// It automatically reconnects on disconnection until ctx is cancelled.
+// It automatically reconnects on disconnection until ctx is cancelled,
+// except that it returns an ErrAuthFailed error when the server rejects the
+// credentials, and an ErrTransient error once cfg.MaxReconnectAttempts (if
+// non-zero) is reached. It
+// also reconnects immediately, bypassing backoff, whenever c.resolver's
+// Watch channel fires – the registry equivalent of noticing the cable's
+// been unplugged instead of waiting for a dead connection to time out.
 func (c *Client) Run(ctx context.Context) error {
 	delay := c.cfg.ReconnectInitialDelay
 	attempts := 0
 
+	watch := c.resolver.Watch(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -63,14 +99,57 @@ func (c *Client) Run(ctx context.Context) error {
 		default:
 		}
 
-		err := c.connectAndServe(ctx)
+		connCtx, cancelConn := context.WithCancel(ctx)
+		rediscover := make(chan struct{})
+		if watch != nil {
+			go func() {
+				select {
+				case <-watch:
+					close(rediscover)
+					cancelConn()
+				case <-connCtx.Done():
+				}
+			}()
+		}
+
+		err := c.connectAndServe(connCtx)
+		cancelConn()
+		c.setConnected(false)
+		// connectAndServe only closes the connection itself on an auth
+		// failure; on every other return (clean shutdown, forced rediscovery,
+		// or a transient read-loop error) the connection is still open and
+		// the read-deadline goroutine DeadlineConn.Read spawns is still
+		// blocked on the real socket. close() here is what actually reclaims
+		// that goroutine before dial() opens the next one.
+		c.close()
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		forcedRediscovery := false
+		select {
+		case <-rediscover:
+			forcedRediscovery = true
+		default:
+		}
+		if forcedRediscovery {
+			slog.Info("discovery: endpoint changed, reconnecting immediately")
+			delay = c.cfg.ReconnectInitialDelay
+			attempts = 0
+			continue
+		}
 		if err == nil || errors.Is(err, context.Canceled) {
 			return nil
 		}
+		if errors.Is(err, ErrAuthFailed) {
+			return err
+		}
 
 		attempts++
+		c.recordReconnectAttempt()
 		if c.cfg.MaxReconnectAttempts > 0 && attempts >= c.cfg.MaxReconnectAttempts {
-			return fmt.Errorf("max reconnect attempts (%d) reached: %w", c.cfg.MaxReconnectAttempts, err)
+			return fmt.Errorf("%w: max reconnect attempts (%d) reached: %w", ErrTransient, c.cfg.MaxReconnectAttempts, err)
 		}
 
 		slog.Warn("connection lost, reconnecting",
@@ -93,34 +172,82 @@ func (c *Client) Run(ctx context.Context) error {
 	}
 }
 
-// connectAndServe performs a single connect-auth-serve cycle.
-func (c *Client) connectAndServe(ctx context.Context) error {
-	addr := fmt.Sprintf("%s:%d", c.cfg.ServerHost, c.cfg.ServerPort)
-	slog.Info("connecting to server", "addr", addr)
+// dial resolves the current endpoint and opens the wire transport to it,
+// storing the connection on c, ready for authenticate.
+func (c *Client) dial(ctx context.Context) error {
+	ep, err := c.resolver.Current(ctx)
+	if err != nil {
+		return fmt.Errorf("resolve endpoint: %w", err)
+	}
+	addr := ep.String()
+	slog.Info("connecting to server", "addr", addr, "transport", c.cfg.Transport)
 
-	dialer := net.Dialer{Timeout: 10 * time.Second}
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	mc, err := NewMessageConn(c.cfg.Transport)
 	if err != nil {
+		return err
+	}
+	if err := mc.Dial(ctx, addr); err != nil {
 		return fmt.Errorf("dial: %w", err)
 	}
 
-	scanner := bufio.NewScanner(conn)
-	// Allow up to 16 MB messages (for large tool results)
-	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
-
 	c.connMu.Lock()
-	c.conn = conn
-	c.reader = scanner
-	c.writer = bufio.NewWriter(conn)
+	c.mc = mc
 	c.connMu.Unlock()
 
+	// A read deadline of 2*HeartbeatInterval turns a stuck peer or
+	// half-open connection into a reconnect instead of a silent hang:
+	// missing two heartbeats trips it. Every successful read in readLoop
+	// pushes it back out, so it really only fires on true idleness.
+	c.refreshReadDeadline()
+
 	slog.Info("connected to server", "addr", addr)
+	return nil
+}
+
+// Bind performs a single connect-and-authenticate cycle using cfg.BindCode
+// and then disconnects; it does not enter the long-running read/heartbeat
+// loop. It's used by the "bind" CLI subcommand to provision (or refresh) a
+// device token without running the agent. On success, DeviceID/ProjectID
+// report the enrolled identity and the token has already been saved to
+// cfg.TokenFile by processAuthResult.
+func (c *Client) Bind(ctx context.Context) error {
+	if c.cfg.BindCode == "" {
+		return errors.New("no bind code configured")
+	}
+	if err := c.dial(ctx); err != nil {
+		return err
+	}
+	defer c.close()
+
+	return c.authenticate(ctx)
+}
+
+// DeviceID returns the device ID learned from the most recent successful
+// authentication.
+func (c *Client) DeviceID() string { return c.deviceID }
+
+// ProjectID returns the project ID learned from the most recent successful
+// authentication.
+func (c *Client) ProjectID() string { return c.projectID }
+
+// connectAndServe performs a single connect-auth-serve cycle. Errors it
+// returns are always wrapped in either ErrAuthFailed or ErrTransient so Run
+// (and, above it, internal/supervisor) can classify them without string
+// matching.
+func (c *Client) connectAndServe(ctx context.Context) error {
+	if err := c.dial(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrTransient, err)
+	}
 
 	// Authenticate
 	if err := c.authenticate(ctx); err != nil {
 		c.close()
-		return fmt.Errorf("auth: %w", err)
+		if errors.Is(err, ErrAuthFailed) {
+			return err
+		}
+		return fmt.Errorf("%w: auth: %w", ErrTransient, err)
 	}
+	c.setConnected(true)
 
 	slog.Info("authenticated",
 		"device_id", c.deviceID,
@@ -133,7 +260,10 @@ func (c *Client) connectAndServe(ctx context.Context) error {
 	go c.heartbeatLoop(heartbeatCtx)
 
 	// Main read loop
-	return c.readLoop(ctx)
+	if err := c.readLoop(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrTransient, err)
+	}
+	return nil
 }
 
 // authenticate sends the auth request and reads the response directly using
@@ -152,6 +282,15 @@ func (c *Client) authenticate(ctx context.Context) error {
 		params.DeviceToken = c.cfg.DeviceToken
 		slog.Debug("authenticating with device token")
 	} else if c.cfg.BindCode != "" {
+		if c.cfg.OperatorAuthRequired {
+			if c.operatorAuth == nil {
+				return errors.New("operator auth required but no operator auth file is configured")
+			}
+			if err := c.operatorAuth.Confirm(c.cfg.OperatorPromptSocketPath()); err != nil {
+				return fmt.Errorf("bind code rejected: %w", err)
+			}
+			slog.Info("operator confirmed bind code")
+		}
 		params.BindCode = c.cfg.BindCode
 		slog.Debug("authenticating with bind code", "bind_code", c.cfg.BindCode)
 	} else {
@@ -171,8 +310,9 @@ func (c *Client) authenticate(ctx context.Context) error {
 
 	slog.Debug("auth request sent, waiting for response")
 
-	// Read the auth response directly using the shared scanner.
-	// We use a goroutine + select so we can respect context cancellation and timeout.
+	// Read the auth response directly off the connection, before readLoop
+	// starts. We use a goroutine + select so we can respect context
+	// cancellation and timeout.
 	type readResult struct {
 		raw []byte
 		err error
@@ -180,17 +320,11 @@ func (c *Client) authenticate(ctx context.Context) error {
 	ch := make(chan readResult, 1)
 
 	go func() {
-		if !c.reader.Scan() {
-			if err := c.reader.Err(); err != nil {
-				ch <- readResult{err: fmt.Errorf("read auth response: %w", err)}
-			} else {
-				ch <- readResult{err: errors.New("connection closed before auth response")}
-			}
+		raw, err := c.mc.ReadMessage()
+		if err != nil {
+			ch <- readResult{err: fmt.Errorf("read auth response: %w", err)}
 			return
 		}
-		// Copy bytes since scanner reuses the buffer
-		raw := make([]byte, len(c.reader.Bytes()))
-		copy(raw, c.reader.Bytes())
 		ch <- readResult{raw: raw}
 	}()
 
@@ -216,7 +350,7 @@ func (c *Client) authenticate(ctx context.Context) error {
 // processAuthResult handles the parsed auth response.
 func (c *Client) processAuthResult(resp *protocol.Response) error {
 	if resp.Error != nil {
-		return fmt.Errorf("auth rejected: [%d] %s", resp.Error.Code, resp.Error.Message)
+		return fmt.Errorf("%w: [%d] %s", ErrAuthFailed, resp.Error.Code, resp.Error.Message)
 	}
 
 	var result protocol.AuthResult
@@ -226,6 +360,7 @@ func (c *Client) processAuthResult(resp *protocol.Response) error {
 
 	c.deviceID = result.DeviceID
 	c.projectID = result.ProjectID
+	c.tokenIssuedAt = time.Now()
 
 	// Save token on first registration
 	if result.DeviceToken != "" {
@@ -269,7 +404,7 @@ func (c *Client) sendRequest(ctx context.Context, method string, params interfac
 	}
 }
 
-// readLoop reads newline-delimited JSON messages using the shared scanner.
+// readLoop reads messages off the connection until it closes or ctx is done.
 func (c *Client) readLoop(ctx context.Context) error {
 	for {
 		select {
@@ -278,16 +413,14 @@ func (c *Client) readLoop(ctx context.Context) error {
 		default:
 		}
 
-		if !c.reader.Scan() {
-			if err := c.reader.Err(); err != nil {
-				return fmt.Errorf("read: %w", err)
-			}
-			return errors.New("connection closed by server")
+		raw, err := c.mc.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
 		}
 
-		// Copy bytes since scanner reuses the buffer
-		raw := make([]byte, len(c.reader.Bytes()))
-		copy(raw, c.reader.Bytes())
+		// Any successful read means the connection is alive; push the idle
+		// deadline back out rather than requiring a heartbeat specifically.
+		c.refreshReadDeadline()
 
 		if len(raw) == 0 {
 			continue
@@ -295,10 +428,13 @@ func (c *Client) readLoop(ctx context.Context) error {
 
 		slog.Debug("received message", "size", len(raw))
 
-		// Determine if response or request
-		if protocol.IsResponse(raw) {
+		// Determine if response, a single request, or a JSON-RPC batch array
+		switch {
+		case protocol.IsResponse(raw):
 			c.handleResponse(raw)
-		} else {
+		case protocol.IsBatch(raw):
+			go c.handleBatch(ctx, raw)
+		default:
 			go c.handleRequest(ctx, raw)
 		}
 	}
@@ -328,7 +464,8 @@ func (c *Client) handleResponse(raw []byte) {
 	}
 }
 
-// handleRequest dispatches incoming server requests (tools/list, tools/call, ping).
+// handleRequest dispatches a single incoming server request (tools/list,
+// tools/call, ping) and writes its response, if any.
 func (c *Client) handleRequest(ctx context.Context, raw []byte) {
 	var req protocol.Request
 	if err := json.Unmarshal(raw, &req); err != nil {
@@ -338,78 +475,175 @@ func (c *Client) handleRequest(ctx context.Context, raw []byte) {
 
 	slog.Debug("handling request", "method", req.Method)
 
+	if resp := c.dispatchRequest(ctx, req); resp != nil {
+		c.writeMessage(resp)
+	}
+}
+
+// maxBatchConcurrency bounds how many elements of a single JSON-RPC batch
+// run at once, so one oversized batch can't exhaust every tool goroutine.
+const maxBatchConcurrency = 8
+
+// handleBatch dispatches a JSON-RPC 2.0 batch request (`[req1, req2, ...]`):
+// each element is routed through dispatchRequest concurrently, bounded by
+// maxBatchConcurrency, with responses collected preserving request order.
+// Per spec, notifications produce no entry in the reply array; if every
+// element was a notification, no reply is sent. A batch that fails to decode
+// gets a single Parse error, and an empty batch a single Invalid Request error.
+func (c *Client) handleBatch(ctx context.Context, raw []byte) {
+	var reqs []protocol.Request
+	if err := json.Unmarshal(raw, &reqs); err != nil {
+		c.writeMessage(protocol.NewErrorResponse(nil, protocol.ErrParseError, fmt.Sprintf("Invalid batch: %v", err)))
+		return
+	}
+	if len(reqs) == 0 {
+		c.writeMessage(protocol.NewErrorResponse(nil, protocol.ErrInvalidRequest, "Batch must not be empty"))
+		return
+	}
+
+	responses := make([]*protocol.Response, len(reqs))
+	sem := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, req := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, req protocol.Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			responses[i] = c.dispatchRequest(ctx, req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	out, err := protocol.BatchResponse(responses)
+	if err != nil {
+		slog.Error("failed to marshal batch response", "error", err)
+		return
+	}
+	if out == nil {
+		return
+	}
+	if err := c.writeRaw(out); err != nil {
+		slog.Warn("failed to write batch response", "error", err)
+	}
+}
+
+// dispatchRequest routes a single JSON-RPC request or notification to its
+// handler and returns the response to send, or nil when none is required –
+// either because req is a notification, or because the method replies
+// out-of-band (a ping notification gets its own "pong" notification rather
+// than a correlated response).
+func (c *Client) dispatchRequest(ctx context.Context, req protocol.Request) *protocol.Response {
 	switch req.Method {
 	case "ping":
-		c.handlePing(req.ID)
+		if req.ID == nil {
+			pong, _ := protocol.NewNotification("pong", nil)
+			c.writeMessage(pong)
+			return nil
+		}
+		return c.buildPingResponse(req.ID)
 	case "tools/list":
-		c.handleToolsList(req.ID)
+		return c.buildToolsListResponse(req.ID)
 	case "tools/call":
-		c.handleToolsCall(ctx, req.ID, req.Params)
+		return c.buildToolsCallResponse(ctx, req.ID, req.Params)
 	default:
-		if req.ID != nil {
-			resp := protocol.NewErrorResponse(req.ID, protocol.ErrMethodNotFound,
-				fmt.Sprintf("Method not found: %s", req.Method))
-			c.writeMessage(resp)
+		if req.ID == nil {
+			return nil
 		}
+		return protocol.NewErrorResponse(req.ID, protocol.ErrMethodNotFound,
+			fmt.Sprintf("Method not found: %s", req.Method))
 	}
 }
 
-// handlePing responds to server ping.
-func (c *Client) handlePing(id *json.RawMessage) {
-	if id != nil {
-		result := map[string]interface{}{
-			"pong":      true,
-			"timestamp": time.Now().Unix(),
-		}
-		resp, _ := protocol.NewResponse(id, result)
-		c.writeMessage(resp)
-	} else {
-		// Notification ping -> respond with pong notification
-		pong, _ := protocol.NewNotification("pong", nil)
-		c.writeMessage(pong)
+// buildPingResponse answers a correlated ping request.
+func (c *Client) buildPingResponse(id *json.RawMessage) *protocol.Response {
+	result := map[string]interface{}{
+		"pong":      true,
+		"timestamp": time.Now().Unix(),
 	}
+	resp, _ := protocol.NewResponse(id, result)
+	return resp
 }
 
-// handleToolsList returns the tool definitions from the registry.
-func (c *Client) handleToolsList(id *json.RawMessage) {
+// buildToolsListResponse returns the tool definitions from the registry.
+func (c *Client) buildToolsListResponse(id *json.RawMessage) *protocol.Response {
 	defs := c.registry.ListTools()
 	result := protocol.ToolsListResult{Tools: defs}
 	resp, err := protocol.NewResponse(id, result)
 	if err != nil {
 		slog.Error("failed to build tools/list response", "error", err)
-		return
+		return nil
 	}
-	c.writeMessage(resp)
+	return resp
 }
 
-// handleToolsCall dispatches a tool call to the registry and returns the result.
-func (c *Client) handleToolsCall(ctx context.Context, id *json.RawMessage, paramsRaw json.RawMessage) {
+// buildToolsCallResponse dispatches a tool call to the registry and builds
+// its response. Tools that implement tools.Streamer emit incremental
+// "tools/progress" notifications while they run; clients that ignore those
+// still get the full result in the final response.
+func (c *Client) buildToolsCallResponse(ctx context.Context, id *json.RawMessage, paramsRaw json.RawMessage) *protocol.Response {
 	start := time.Now()
 
 	var params protocol.ToolCallParams
 	if err := json.Unmarshal(paramsRaw, &params); err != nil {
-		resp := protocol.NewErrorResponse(id, protocol.ErrInvalidParams, "Invalid tools/call params")
-		c.writeMessage(resp)
-		return
+		if id == nil {
+			return nil
+		}
+		return protocol.NewErrorResponse(id, protocol.ErrInvalidParams, "Invalid tools/call params")
 	}
 
-	slog.Info("tool call", "tool", params.Name, "args_keys", mapKeys(params.Arguments))
+	callID := callIDFromRequest(id)
+	ctx = protocol.ContextWithRequestID(ctx, callID)
+
+	baggage := []any{"call_id", callID, "device_id", c.deviceID, "project_id", c.projectID, "tool", params.Name}
+	if params.TraceID != "" {
+		baggage = append(baggage, "trace_id", params.TraceID)
+	}
+	ctx = logctx.WithFields(ctx, baggage...)
 
-	result := c.registry.CallTool(ctx, params.Name, params.Arguments)
+	slog.InfoContext(ctx, "tool call", "tool", params.Name, "args_keys", mapKeys(params.Arguments))
+
+	var seq atomic.Int64
+	emit := func(stream, chunk string) {
+		notif, _ := protocol.NewNotification(protocol.MethodToolsProgress, protocol.ToolProgressParams{
+			CallID:   callID,
+			Sequence: int(seq.Add(1)),
+			Chunk:    chunk,
+			Stream:   stream,
+		})
+		if err := c.writeMessage(notif); err != nil {
+			slog.Warn("failed to send tools/progress notification", "error", err)
+		}
+	}
+
+	result := c.registry.CallToolStreaming(ctx, params.Name, params.Arguments, emit)
+
+	if n := seq.Load(); n > 0 {
+		done, _ := protocol.NewNotification(protocol.MethodToolsProgress, protocol.ToolProgressParams{
+			CallID:   callID,
+			Sequence: int(seq.Add(1)),
+			Done:     true,
+		})
+		c.writeMessage(done)
+	}
 
 	elapsed := time.Since(start)
-	slog.Info("tool call completed",
+	slog.InfoContext(ctx, "tool call completed",
 		"tool", params.Name,
 		"is_error", result.IsError,
-		"elapsed", elapsed,
+		"elapsed_ms", elapsed.Milliseconds(),
 	)
 
+	if id == nil {
+		return nil
+	}
 	resp, err := protocol.NewResponse(id, result)
 	if err != nil {
 		slog.Error("failed to build tools/call response", "error", err)
-		return
+		return nil
 	}
-	c.writeMessage(resp)
+	return resp
 }
 
 // heartbeatLoop sends periodic heartbeat messages.
@@ -427,6 +661,7 @@ func (c *Client) heartbeatLoop(ctx context.Context) {
 				slog.Warn("heartbeat send failed", "error", err)
 				return
 			}
+			c.recordTokenAge()
 			slog.Debug("heartbeat sent")
 		}
 	}
@@ -438,38 +673,84 @@ func (c *Client) writeMessage(msg interface{}) error {
 	if err != nil {
 		return err
 	}
+	return c.writeRaw(data)
+}
 
+// writeRaw sends an already-serialized JSON-RPC message. Used for batch
+// replies, which are assembled as a raw array rather than a single
+// marshalable value.
+func (c *Client) writeRaw(data []byte) error {
 	c.writeMu.Lock()
 	defer c.writeMu.Unlock()
 
 	c.connMu.Lock()
-	w := c.writer
+	mc := c.mc
 	c.connMu.Unlock()
 
-	if w == nil {
+	if mc == nil {
 		return errors.New("no connection")
 	}
 
-	if _, err := w.Write(data); err != nil {
-		return err
+	if c.cfg.WriteTimeout > 0 {
+		mc.SetWriteDeadline(time.Now().Add(c.cfg.WriteTimeout))
 	}
-	if err := w.WriteByte('\n'); err != nil {
-		return err
+
+	return mc.WriteMessage(data)
+}
+
+// refreshReadDeadline pushes the connection's read deadline out to
+// 2*HeartbeatInterval from now. Called once after connecting and again
+// after every successful read, so it only trips when the connection has
+// truly gone idle for two heartbeat periods.
+func (c *Client) refreshReadDeadline() {
+	c.connMu.Lock()
+	mc := c.mc
+	c.connMu.Unlock()
+
+	if mc == nil || c.cfg.HeartbeatInterval <= 0 {
+		return
+	}
+	mc.SetReadDeadline(time.Now().Add(2 * c.cfg.HeartbeatInterval))
+}
+
+// setConnected, recordReconnectAttempt, and recordTokenAge forward to c.metrics
+// when it's configured; they're no-ops otherwise.
+func (c *Client) setConnected(connected bool) {
+	if c.metrics != nil {
+		c.metrics.SetConnected(connected)
+	}
+}
+
+func (c *Client) recordReconnectAttempt() {
+	if c.metrics != nil {
+		c.metrics.ReconnectAttempt()
 	}
-	return w.Flush()
 }
 
-// close cleanly shuts down the TCP connection.
+func (c *Client) recordTokenAge() {
+	if c.metrics != nil && !c.tokenIssuedAt.IsZero() {
+		c.metrics.SetTokenAge(time.Since(c.tokenIssuedAt))
+	}
+}
+
+// close cleanly shuts down the connection.
 func (c *Client) close() {
 	c.connMu.Lock()
 	defer c.connMu.Unlock()
 
-	if c.conn != nil {
-		c.conn.Close()
-		c.conn = nil
-		c.reader = nil
-		c.writer = nil
+	if c.mc != nil {
+		c.mc.Close()
+		c.mc = nil
+	}
+}
+
+// callIDFromRequest derives a correlation ID for "tools/progress" notifications
+// from the originating request's JSON-RPC id, stripping any surrounding quotes.
+func callIDFromRequest(id *json.RawMessage) string {
+	if id == nil {
+		return ""
 	}
+	return strings.Trim(string(*id), `"`)
 }
 
 // mapKeys extracts the keys of a map for logging.
@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tgoai/tgo-device-agent/internal/config"
+	"github.com/tgoai/tgo-device-agent/internal/protocol"
+)
+
+// fakeConn is a minimal MessageConn that records every message written to
+// it, for asserting on handleBatch's output without a real socket.
+type fakeConn struct {
+	mu      sync.Mutex
+	written [][]byte
+}
+
+func (f *fakeConn) Dial(ctx context.Context, addr string) error { return nil }
+func (f *fakeConn) ReadMessage() ([]byte, error)                { return nil, nil }
+func (f *fakeConn) SetReadDeadline(t time.Time) error           { return nil }
+func (f *fakeConn) SetWriteDeadline(t time.Time) error          { return nil }
+func (f *fakeConn) Close() error                                { return nil }
+
+func (f *fakeConn) WriteMessage(data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.written = append(f.written, append([]byte(nil), data...))
+	return nil
+}
+
+func (f *fakeConn) messages() [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]byte(nil), f.written...)
+}
+
+func newTestClient() (*Client, *fakeConn) {
+	fc := &fakeConn{}
+	return &Client{cfg: &config.Config{}, mc: fc}, fc
+}
+
+func TestHandleBatch_MixedRequestsAndNotifications(t *testing.T) {
+	c, fc := newTestClient()
+
+	// A correlated ping (gets a reply in the batch array) alongside a ping
+	// notification (no id – gets its own out-of-band "pong" notification
+	// instead, per dispatchRequest).
+	raw := []byte(`[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","method":"ping"}
+	]`)
+	c.handleBatch(context.Background(), raw)
+
+	msgs := fc.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2 (one out-of-band pong, one batch reply): %v", len(msgs), msgs)
+	}
+
+	// The batch reply (a JSON array) is always written last: handleBatch
+	// only writes it after every per-element goroutine, including the
+	// notification's, has finished.
+	var batch []protocol.Response
+	if err := json.Unmarshal(msgs[len(msgs)-1], &batch); err != nil {
+		t.Fatalf("last message isn't the batch reply array: %v (raw: %s)", err, msgs[len(msgs)-1])
+	}
+	if len(batch) != 1 {
+		t.Fatalf("batch reply has %d entries, want 1 (the notification must not appear)", len(batch))
+	}
+	var id int
+	if err := json.Unmarshal(*batch[0].ID, &id); err != nil || id != 1 {
+		t.Fatalf("batch reply id = %v (err %v), want 1", id, err)
+	}
+}
+
+func TestHandleBatch_AllNotifications(t *testing.T) {
+	c, fc := newTestClient()
+
+	raw := []byte(`[
+		{"jsonrpc":"2.0","method":"ping"},
+		{"jsonrpc":"2.0","method":"ping"}
+	]`)
+	c.handleBatch(context.Background(), raw)
+
+	// Each notification gets its own out-of-band pong, but handleBatch must
+	// not additionally write an empty "[]" batch reply.
+	msgs := fc.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want exactly 2 out-of-band pongs and no batch reply: %v", len(msgs), msgs)
+	}
+	for _, m := range msgs {
+		if string(m) == "[]" {
+			t.Fatalf("handleBatch wrote an empty array reply %q, want no reply at all for an all-notification batch", m)
+		}
+	}
+}
+
+func TestHandleBatch_MalformedElementRejectsWholeBatch(t *testing.T) {
+	c, fc := newTestClient()
+
+	// The second element is invalid JSON, so the whole batch fails to
+	// decode into []protocol.Request – there's no way to salvage the
+	// well-formed first element, so the server must respond with exactly
+	// one Parse error for the batch as a whole.
+	raw := []byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":}]`)
+	c.handleBatch(context.Background(), raw)
+
+	msgs := fc.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want exactly 1 error response: %v", len(msgs), msgs)
+	}
+	var resp protocol.Response
+	if err := json.Unmarshal(msgs[0], &resp); err != nil {
+		t.Fatalf("unmarshal error response: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != protocol.ErrParseError {
+		t.Fatalf("response error = %+v, want code %d", resp.Error, protocol.ErrParseError)
+	}
+}
+
+func TestHandleBatch_Empty(t *testing.T) {
+	c, fc := newTestClient()
+
+	c.handleBatch(context.Background(), []byte(`[]`))
+
+	msgs := fc.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want exactly 1 error response: %v", len(msgs), msgs)
+	}
+	var resp protocol.Response
+	if err := json.Unmarshal(msgs[0], &resp); err != nil {
+		t.Fatalf("unmarshal error response: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != protocol.ErrInvalidRequest {
+		t.Fatalf("response error = %+v, want code %d", resp.Error, protocol.ErrInvalidRequest)
+	}
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MessageConn abstracts the wire-level connection to tgo-device-control so
+// the rest of Client (auth, heartbeat, dispatch) doesn't care whether
+// messages travel over raw TCP or a WebSocket. Each call to ReadMessage
+// returns exactly one JSON-RPC message – a request, response, notification,
+// or batch array – however the underlying transport frames it (newlines
+// for TCP, message boundaries for WebSocket).
+type MessageConn interface {
+	// Dial establishes the connection to addr ("host:port").
+	Dial(ctx context.Context, addr string) error
+	// ReadMessage blocks until the next whole message arrives.
+	ReadMessage() ([]byte, error)
+	// WriteMessage sends one whole message.
+	WriteMessage(data []byte) error
+	// SetReadDeadline/SetWriteDeadline bound the next ReadMessage/WriteMessage
+	// call; a zero Time clears the deadline.
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// NewMessageConn constructs the MessageConn implementation for scheme –
+// "tcp" (the default), "ws", or "wss".
+func NewMessageConn(scheme string) (MessageConn, error) {
+	switch scheme {
+	case "", "tcp":
+		return newTCPConn(), nil
+	case "ws":
+		return newWSConn(false), nil
+	case "wss":
+		return newWSConn(true), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q (expected tcp, ws, or wss)", scheme)
+	}
+}
@@ -0,0 +1,15 @@
+package transport
+
+import "errors"
+
+// ErrAuthFailed indicates the server explicitly rejected the device's
+// credentials (an invalid/revoked token, or a bad bind code) rather than a
+// network hiccup. It isn't recoverable by retrying the same credentials;
+// internal/supervisor treats it as fatal and wipes the saved token.
+var ErrAuthFailed = errors.New("transport: authentication failed")
+
+// ErrTransient wraps a network or protocol-level error — a failed dial, a
+// dropped connection, an exhausted internal reconnect budget — where the
+// right response is to retry with backoff. internal/supervisor treats it as
+// recoverable.
+var ErrTransient = errors.New("transport: transient connection error")
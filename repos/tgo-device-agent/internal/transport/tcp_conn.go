@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/tgoai/tgo-device-agent/internal/protocol"
+)
+
+// tcpConn is the default MessageConn: a raw TCP socket carrying
+// newline-delimited JSON, the agent's original wire format. It wraps the
+// dialed socket in a protocol.DeadlineConn so read/write deadlines work the
+// same way here as they do for wsConn.
+type tcpConn struct {
+	conn   *protocol.DeadlineConn
+	reader *bufio.Scanner
+	writer *bufio.Writer
+}
+
+func newTCPConn() *tcpConn { return &tcpConn{} }
+
+func (c *tcpConn) Dial(ctx context.Context, addr string) error {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	raw, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	c.conn = protocol.NewDeadlineConn(raw)
+	c.reader = bufio.NewScanner(c.conn)
+	// Allow up to 16 MB messages (for large tool results)
+	c.reader.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	c.writer = bufio.NewWriter(c.conn)
+	return nil
+}
+
+func (c *tcpConn) ReadMessage() ([]byte, error) {
+	if !c.reader.Scan() {
+		if err := c.reader.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	// Copy bytes since the scanner reuses its internal buffer.
+	raw := make([]byte, len(c.reader.Bytes()))
+	copy(raw, c.reader.Bytes())
+	return raw, nil
+}
+
+func (c *tcpConn) WriteMessage(data []byte) error {
+	if _, err := c.writer.Write(data); err != nil {
+		return err
+	}
+	if err := c.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+func (c *tcpConn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *tcpConn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
+
+func (c *tcpConn) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
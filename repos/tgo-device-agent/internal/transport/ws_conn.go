@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// wsConn is a MessageConn backed by a WebSocket, one whole JSON-RPC message
+// per text frame. Selecting "ws"/"wss" in Config.Transport lets the agent
+// sit behind an HTTPS-terminating load balancer or reverse proxy that a raw
+// TCP socket can't pass through.
+//
+// websocket.Conn's Read/Write take a context rather than exposing
+// SetReadDeadline/SetWriteDeadline directly, so wsConn tracks the current
+// deadlines itself and derives a per-call context from them.
+type wsConn struct {
+	tls  bool
+	conn *websocket.Conn
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func newWSConn(tls bool) *wsConn { return &wsConn{tls: tls} }
+
+func (c *wsConn) Dial(ctx context.Context, addr string) error {
+	scheme := "ws"
+	if c.tls {
+		scheme = "wss"
+	}
+	conn, _, err := websocket.Dial(ctx, fmt.Sprintf("%s://%s/agent", scheme, addr), nil)
+	if err != nil {
+		return err
+	}
+	conn.SetReadLimit(16 * 1024 * 1024) // match tcpConn's max message size
+	c.conn = conn
+	return nil
+}
+
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	ctx, cancel := c.deadlineContext(c.readDeadline)
+	defer cancel()
+	_, data, err := c.conn.Read(ctx)
+	return data, err
+}
+
+func (c *wsConn) WriteMessage(data []byte) error {
+	ctx, cancel := c.deadlineContext(c.writeDeadline)
+	defer cancel()
+	return c.conn.Write(ctx, websocket.MessageText, data)
+}
+
+func (c *wsConn) deadlineContext(deadline time.Time) (context.Context, context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *wsConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *wsConn) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close(websocket.StatusNormalClosure, "")
+}